@@ -0,0 +1,247 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// astarLeaf is an element of a partial recipe tree that still needs a
+// recipe, level is its distance from the target
+type astarLeaf struct {
+	element string
+	level   int
+}
+
+// astarState is a partial recipe tree: the recipes applied so far (through
+// parent) and the leaves left. g is the cost of the applied part, f adds
+// the heuristic of the leaves.
+type astarState struct {
+	parent *astarState
+	recipe *solverRecipe // applied to reach this state, nil at the root
+	leaves []astarLeaf
+	g, f   float64
+	// tier is the highest tier of the leaves, deeper progress breaks ties
+	tier int
+}
+
+// astarQueue is a min-heap of states by f; among equal f the one with the
+// most cost already applied, then the lowest leaf tier, is closest to done
+type astarQueue []*astarState
+
+func (q astarQueue) Len() int { return len(q) }
+func (q astarQueue) Less(i, j int) bool {
+	if q[i].f != q[j].f {
+		return q[i].f < q[j].f
+	}
+	if q[i].g != q[j].g {
+		return q[i].g > q[j].g
+	}
+	return q[i].tier < q[j].tier
+}
+func (q astarQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *astarQueue) Push(x interface{}) { *q = append(*q, x.(*astarState)) }
+func (q *astarQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// astarSearch holds what the heuristic needs
+type astarSearch struct {
+	obj   Objective
+	graph *graphData
+	// minUnit is the lowest weight of a crafted element, 1 unless weights
+	// say otherwise
+	minUnit float64
+}
+
+func (s *astarSearch) unit(element string) float64 {
+	if s.obj.Name == OptimizeWeighted {
+		return s.obj.weight(element)
+	}
+	return 1
+}
+
+// h is the admissible estimate of what making leaf still costs. Its
+// shallowest recipe chain, computed under the tier rule, has minDepth
+// combinations: with steps that many, with weights the leaf itself plus
+// the cheapest weight for the rest of the chain, with depth the chain
+// below the level of the leaf.
+func (s *astarSearch) h(leaf astarLeaf) float64 {
+	d := s.graph.minDepth.cost[leaf.element]
+	switch s.obj.Name {
+	case OptimizeDepth:
+		return float64(leaf.level) + d
+	case OptimizeWeighted:
+		return s.unit(leaf.element) + max(d-1, 0)*s.minUnit
+	}
+	return d
+}
+
+// evaluate sets f and tier of st from g and its leaves
+func (s *astarSearch) evaluate(st *astarState) {
+	st.f, st.tier = st.g, 0
+	for _, leaf := range st.leaves {
+		if s.obj.Name == OptimizeDepth {
+			st.f = max(st.f, s.h(leaf))
+		} else {
+			st.f += s.h(leaf)
+		}
+		st.tier = max(st.tier, tierMap[leaf.element])
+	}
+}
+
+// key identifies the leaves of a state: states with the same leaves have
+// the same completions
+func (s *astarSearch) key(st *astarState) string {
+	parts := make([]string, len(st.leaves))
+	for i, leaf := range st.leaves {
+		parts[i] = leaf.element
+		if s.obj.Name == OptimizeDepth {
+			parts[i] += "@" + strconv.Itoa(leaf.level)
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "|")
+}
+
+// expand returns the successors of st: the leaf of the highest tier made
+// with each of its recipes. Base ingredients are paid and dropped at once.
+func (s *astarSearch) expand(st *astarState) []*astarState {
+	pick := 0
+	for i, leaf := range st.leaves {
+		best := st.leaves[pick]
+		if tierMap[leaf.element] > tierMap[best.element] || (tierMap[leaf.element] == tierMap[best.element] && leaf.element < best.element) {
+			pick = i
+		}
+	}
+	leaf := st.leaves[pick]
+	rest := make([]astarLeaf, 0, len(st.leaves)+1)
+	rest = append(rest, st.leaves[:pick]...)
+	rest = append(rest, st.leaves[pick+1:]...)
+
+	var next []*astarState
+	for _, r := range validRecipes(leaf.element) {
+		_, okA := s.graph.minDepth.cost[r.a]
+		_, okB := s.graph.minDepth.cost[r.b]
+		if !okA || !okB {
+			continue
+		}
+		r := r
+		child := &astarState{parent: st, recipe: &r, leaves: append([]astarLeaf(nil), rest...)}
+		if s.obj.Name == OptimizeDepth {
+			child.g = max(st.g, float64(leaf.level+1))
+		} else {
+			child.g = st.g + s.unit(leaf.element)
+		}
+		for _, ingr := range []string{r.a, r.b} {
+			if baseElements[ingr] {
+				if s.obj.Name == OptimizeWeighted {
+					child.g += s.obj.weight(ingr)
+				}
+				continue
+			}
+			child.leaves = append(child.leaves, astarLeaf{element: ingr, level: leaf.level + 1})
+		}
+		s.evaluate(child)
+		next = append(next, child)
+	}
+	return next
+}
+
+// steps rebuilds the recipe tree of a finished state, ingredients before
+// results. Leaves of the same element are interchangeable, so the recipes
+// applied to an element are handed out in order.
+func (st *astarState) steps(target string) []string {
+	applied := make(map[string][]solverRecipe)
+	var chain []*solverRecipe
+	for s := st; s != nil && s.recipe != nil; s = s.parent {
+		chain = append(chain, s.recipe)
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		r := chain[i]
+		applied[r.element] = append(applied[r.element], *r)
+	}
+	steps := []string{}
+	var build func(element string)
+	build = func(element string) {
+		if baseElements[element] || len(applied[element]) == 0 {
+			return
+		}
+		r := applied[element][0]
+		applied[element] = applied[element][1:]
+		build(r.a)
+		build(r.b)
+		steps = append(steps, r.step())
+	}
+	build(target)
+	return steps
+}
+
+// astarPath runs A* over partial recipe trees of target, expanding the
+// state of the lowest f = g + h first, and returns the first finished
+// tree: with an admissible h it minimizes obj like optimal does. The
+// distinct elements of OptimizeElements are not a tree cost and are
+// answered by the exact search. NodesVisited is the number of states
+// expanded; more than the configured budget gives up with
+// ErrSearchBudget.
+func astarPath(ctx context.Context, target string, obj Objective) (PathResult, bool, error) {
+	if obj.Name == OptimizeElements {
+		return exactPath(ctx, target, obj)
+	}
+	start := time.Now()
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	s := &astarSearch{obj: obj, graph: cachedGraph(), minUnit: 1}
+	if obj.Name == OptimizeWeighted {
+		for _, weights := range []map[string]float64{elementWeights, obj.Weights} {
+			for e := range weights {
+				if !baseElements[e] {
+					s.minUnit = min(s.minUnit, obj.weight(e))
+				}
+			}
+		}
+	}
+	if _, ok := s.graph.minDepth.cost[target]; !ok {
+		return PathResult{Runtime: time.Since(start)}, false, nil
+	}
+
+	root := &astarState{}
+	if baseElements[target] {
+		root.g = obj.baseCost(target)
+	} else {
+		root.leaves = []astarLeaf{{element: target}}
+	}
+	s.evaluate(root)
+	q := &astarQueue{root}
+	closed := make(map[string]float64)
+	expanded := 0
+	for q.Len() > 0 && ctx.Err() == nil {
+		st := heap.Pop(q).(*astarState)
+		if len(st.leaves) == 0 {
+			cost := st.g
+			return PathResult{Steps: st.steps(target), Runtime: time.Since(start), NodesVisited: expanded, Cost: &cost, Objective: obj.Name}, true, nil
+		}
+		key := s.key(st)
+		if g, ok := closed[key]; ok && g <= st.g {
+			continue
+		}
+		closed[key] = st.g
+		expanded++
+		if expanded > appConfig.AStarMaxNodes {
+			return PathResult{Runtime: time.Since(start), NodesVisited: expanded}, false,
+				newAPIError(ErrSearchBudget, fmt.Sprintf("astar gave up after %d states", appConfig.AStarMaxNodes))
+		}
+		for _, child := range s.expand(st) {
+			heap.Push(q, child)
+		}
+	}
+	return PathResult{Runtime: time.Since(start), NodesVisited: expanded}, false, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Roles, admin can do everything read can
+const (
+	RoleRead  = "read"
+	RoleAdmin = "admin"
+)
+
+// APIKey grants its role to whoever presents it
+type APIKey struct {
+	Name string `json:"name" yaml:"name" toml:"name"`
+	Key  string `json:"key" yaml:"key" toml:"key"`
+	Role string `json:"role" yaml:"role" toml:"role"`
+}
+
+func validRole(role string) bool {
+	return role == RoleRead || role == RoleAdmin
+}
+
+// roleAllows reports whether a key with role may call a route requiring want
+func roleAllows(role, want string) bool {
+	return role == RoleAdmin || role == want
+}
+
+func hasAdminKey(cfg *Config) bool {
+	for _, k := range cfg.APIKeys {
+		if k.Role == RoleAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// parseAPIKey parses "role:key" or "role:key:name"
+func parseAPIKey(s string) (APIKey, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 3)
+	if len(parts) < 2 || parts[1] == "" {
+		return APIKey{}, fmt.Errorf("invalid API key entry, want role:key[:name]")
+	}
+	k := APIKey{Role: parts[0], Key: parts[1]}
+	if len(parts) == 3 {
+		k.Name = parts[2]
+	}
+	return k, nil
+}
+
+// loadAPIKeysFile reads one role:key[:name] entry per line, # starts a comment
+func loadAPIKeysFile(path string) ([]APIKey, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read API keys file: %w", err)
+	}
+	defer f.Close()
+
+	var keys []APIKey
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		k, err := parseAPIKey(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNo, err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, scanner.Err()
+}
+
+// requestToken returns the bearer token or X-API-Key header of the request
+func requestToken(c *gin.Context) string {
+	if h := c.GetHeader("Authorization"); strings.HasPrefix(h, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(h, "Bearer "))
+	}
+	return strings.TrimSpace(c.GetHeader("X-API-Key"))
+}
+
+// lookupAPIKey compares against every key in constant time
+func lookupAPIKey(keys []APIKey, token string) (APIKey, bool) {
+	var match APIKey
+	found := false
+	for _, k := range keys {
+		if subtle.ConstantTimeCompare([]byte(k.Key), []byte(token)) == 1 {
+			match = k
+			found = true
+		}
+	}
+	return match, found
+}
+
+// requireRole rejects requests without a key granting role. Read routes stay
+// public unless requireReadKey is enabled.
+func requireRole(role string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if role == RoleRead && !appConfig.RequireReadKey {
+			c.Next()
+			return
+		}
+
+		token := requestToken(c)
+		if token == "" {
+			rejectAuth(c, ErrUnauthorized, "missing API key")
+			return
+		}
+		key, ok := lookupAPIKey(appConfig.APIKeys, token)
+		if !ok {
+			rejectAuth(c, ErrUnauthorized, "invalid API key")
+			return
+		}
+		if !roleAllows(key.Role, role) {
+			rejectAuth(c, ErrForbidden, fmt.Sprintf("key %q has role %s, %s required", key.Name, key.Role, role))
+			return
+		}
+		c.Set("apiKeyName", key.Name)
+		c.Set("apiKeyRole", key.Role)
+		c.Next()
+	}
+}
+
+func rejectAuth(c *gin.Context, code ErrorCode, reason string) {
+	log.Printf("[AUTH] Rejected %s %s from %s: %s\n", c.Request.Method, c.Request.URL.Path, c.ClientIP(), reason)
+	respondError(c, newAPIError(code, ""))
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useAPIKeys sets a read key "read-key" and an admin key "admin-key" for
+// the duration of the test
+func useAPIKeys(t *testing.T, requireReadKey bool) {
+	t.Helper()
+	old := appConfig
+	c := *appConfig
+	c.APIKeys = []APIKey{
+		{Name: "reader", Key: "read-key", Role: RoleRead},
+		{Name: "ops", Key: "admin-key", Role: RoleAdmin},
+	}
+	c.RequireReadKey = requireReadKey
+	appConfig = &c
+	t.Cleanup(func() { appConfig = old })
+}
+
+func TestRouterRoles(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tests := []struct {
+		name        string
+		requireRead bool
+		path        string
+		header      string
+		token       string
+		status      int
+		code        ErrorCode
+	}{
+		{"public read without key", false, "/packs", "", "", http.StatusOK, ""},
+		{"public read ignores a bad key", false, "/packs", "X-API-Key", "nope", http.StatusOK, ""},
+		{"read without key", true, "/packs", "", "", http.StatusUnauthorized, ErrUnauthorized},
+		{"read with a bad key", true, "/packs", "X-API-Key", "nope", http.StatusUnauthorized, ErrUnauthorized},
+		{"read with the read key", true, "/packs", "X-API-Key", "read-key", http.StatusOK, ""},
+		{"read with the admin key", true, "/packs", "Authorization", "Bearer admin-key", http.StatusOK, ""},
+		{"admin without key", false, "/admin/config", "", "", http.StatusUnauthorized, ErrUnauthorized},
+		{"admin with a bad key", false, "/admin/config", "Authorization", "Bearer nope", http.StatusUnauthorized, ErrUnauthorized},
+		{"admin with the read key", false, "/admin/config", "Authorization", "Bearer read-key", http.StatusForbidden, ErrForbidden},
+		{"admin with the read key when reads need one", true, "/admin/config", "X-API-Key", "read-key", http.StatusForbidden, ErrForbidden},
+		{"admin with the admin key", false, "/admin/config", "X-API-Key", "admin-key", http.StatusOK, ""},
+		{"admin with the admin bearer key", true, "/admin/config", "Authorization", "Bearer admin-key", http.StatusOK, ""},
+		{"ping is outside the groups", true, "/ping", "", "", http.StatusOK, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useAPIKeys(t, tt.requireRead)
+			r := newRouter(appConfig)
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.token)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+			if tt.code == "" {
+				return
+			}
+			var body struct {
+				Error struct {
+					Code ErrorCode `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+				t.Fatal(err)
+			}
+			if body.Error.Code != tt.code {
+				t.Errorf("error code %s, want %s", body.Error.Code, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// benchAlgorithm is one solver configuration of /find
+type benchAlgorithm struct {
+	Name string
+	Req  FindRequest
+}
+
+// benchAlgorithms returns the solvers behind /find; multiple-path
+// searches ask for count recipes
+func benchAlgorithms(count int) []benchAlgorithm {
+	return []benchAlgorithm{
+		{"bfs", FindRequest{Method: "bfs", Count: 1}},
+		{"bfs-bidirectional", FindRequest{Method: "bfs", Count: 1, Bidirectional: true}},
+		{"bfs-multiple", FindRequest{Method: "bfs", Count: count}},
+		{"dfs", FindRequest{Method: "dfs", Count: 1}},
+		{"dfs-bidirectional", FindRequest{Method: "dfs", Count: 1, Bidirectional: true}},
+		{"dfs-multiple", FindRequest{Method: "dfs", Count: count}},
+		{"optimal", FindRequest{Method: "optimal", Count: 1}},
+		{"exact", FindRequest{Method: "exact", Count: 1}},
+		{"astar", FindRequest{Method: "astar", Count: 1}},
+		{"iddfs", FindRequest{Method: "iddfs", Count: 1}},
+		{"kbest", FindRequest{Method: "kbest", Count: count}},
+	}
+}
+
+// benchRow is one run of one algorithm on one element
+type benchRow struct {
+	Element   string
+	Tier      int
+	Algorithm string
+	Repeat    int
+	Found     bool
+	Paths     int
+	Steps     int
+	Nodes     int
+	Runtime   time.Duration
+	Valid     bool
+	Error     string
+}
+
+var benchHeader = []string{"element", "tier", "algorithm", "repeat", "found", "paths", "steps", "nodes", "runtime_us", "valid", "error"}
+
+func (r benchRow) record() []string {
+	return []string{
+		r.Element, strconv.Itoa(r.Tier), r.Algorithm, strconv.Itoa(r.Repeat),
+		strconv.FormatBool(r.Found), strconv.Itoa(r.Paths), strconv.Itoa(r.Steps), strconv.Itoa(r.Nodes),
+		strconv.FormatInt(r.Runtime.Microseconds(), 10), strconv.FormatBool(r.Valid), r.Error,
+	}
+}
+
+func parseBenchRecord(rec []string) (benchRow, error) {
+	if len(rec) != len(benchHeader) {
+		return benchRow{}, fmt.Errorf("expected %d columns, got %d", len(benchHeader), len(rec))
+	}
+	var r benchRow
+	var err error
+	atoi := func(s string) int {
+		n, e := strconv.Atoi(s)
+		if e != nil && err == nil {
+			err = e
+		}
+		return n
+	}
+	r.Element, r.Algorithm, r.Error = rec[0], rec[2], rec[10]
+	r.Tier, r.Repeat, r.Paths, r.Steps, r.Nodes = atoi(rec[1]), atoi(rec[3]), atoi(rec[5]), atoi(rec[6]), atoi(rec[7])
+	r.Runtime = time.Duration(atoi(rec[8])) * time.Microsecond
+	r.Found, r.Valid = rec[4] == "true", rec[9] == "true"
+	return r, err
+}
+
+// runBench runs one algorithm on one element and checks every returned path.
+// A run past timeout is cancelled and has stopped when runBench returns, so
+// it does not slow down the runs after it.
+func runBench(ctx context.Context, alg benchAlgorithm, element string, repeat int, timeout time.Duration) benchRow {
+	row := benchRow{Element: element, Tier: tierMap[element], Algorithm: alg.Name, Repeat: repeat}
+	req := alg.Req
+	req.Target = element
+
+	start := time.Now()
+	result, err := findWithTimeout(ctx, req, timeout)
+	row.Runtime = time.Since(start)
+	if err != nil {
+		if apiErr, ok := err.(*APIError); ok {
+			row.Error = string(apiErr.Code)
+		} else {
+			row.Error = err.Error()
+		}
+		return row
+	}
+
+	row.Found = result.Found
+	row.Paths = len(result.Paths)
+	row.Nodes = result.NodesVisited
+	row.Valid = result.Found
+	for i, p := range result.Paths {
+		if i == 0 {
+			row.Steps = len(p.Steps)
+		}
+		if err := validatePath(element, p.Steps); err != nil {
+			row.Valid = false
+			if row.Error == "" {
+				row.Error = "invalid: " + err.Error()
+			}
+		}
+	}
+	return row
+}
+
+// benchSummary aggregates the rows of one algorithm
+type benchSummary struct {
+	Algorithm     string
+	Runs          int
+	Found         int
+	Valid         int
+	Errors        int
+	MedianRuntime time.Duration
+	MeanRuntime   time.Duration
+	MeanNodes     float64
+	MeanSteps     float64
+}
+
+func summarizeBench(rows []benchRow) []benchSummary {
+	byAlg := make(map[string][]benchRow)
+	var order []string
+	for _, r := range rows {
+		if _, ok := byAlg[r.Algorithm]; !ok {
+			order = append(order, r.Algorithm)
+		}
+		byAlg[r.Algorithm] = append(byAlg[r.Algorithm], r)
+	}
+
+	var out []benchSummary
+	for _, alg := range order {
+		s := benchSummary{Algorithm: alg}
+		var runtimes []time.Duration
+		var total time.Duration
+		var nodes, steps float64
+		for _, r := range byAlg[alg] {
+			s.Runs++
+			if r.Found {
+				s.Found++
+				steps += float64(r.Steps)
+			}
+			if r.Valid {
+				s.Valid++
+			}
+			if r.Error != "" {
+				s.Errors++
+			}
+			nodes += float64(r.Nodes)
+			total += r.Runtime
+			runtimes = append(runtimes, r.Runtime)
+		}
+		sort.Slice(runtimes, func(i, j int) bool { return runtimes[i] < runtimes[j] })
+		s.MedianRuntime = runtimes[len(runtimes)/2]
+		s.MeanRuntime = total / time.Duration(s.Runs)
+		s.MeanNodes = nodes / float64(s.Runs)
+		if s.Found > 0 {
+			s.MeanSteps = steps / float64(s.Found)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func printBenchSummary(w io.Writer, summaries []benchSummary) {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ALGORITHM\tRUNS\tFOUND\tVALID\tERRORS\tMEDIAN\tMEAN\tNODES\tSTEPS")
+	for _, s := range summaries {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%.1f%%\t%d\t%s\t%s\t%.1f\t%.1f\n", s.Algorithm, s.Runs,
+			100*float64(s.Found)/float64(s.Runs), 100*float64(s.Valid)/float64(s.Runs), s.Errors,
+			s.MedianRuntime.Round(time.Microsecond), s.MeanRuntime.Round(time.Microsecond), s.MeanNodes, s.MeanSteps)
+	}
+	tw.Flush()
+}
+
+// compareBench lists regressions of rows against a baseline run: elements
+// no longer found or no longer valid, and algorithms whose median runtime
+// grew by more than slowdown
+func compareBench(baseline, rows []benchRow, slowdown float64) []string {
+	type key struct{ element, algorithm string }
+	before := make(map[key]benchRow)
+	for _, r := range baseline {
+		before[key{r.Element, r.Algorithm}] = r
+	}
+
+	var regressions []string
+	reported := make(map[key]bool)
+	for _, r := range rows {
+		k := key{r.Element, r.Algorithm}
+		b, ok := before[k]
+		if !ok || reported[k] {
+			continue
+		}
+		if b.Found && !r.Found {
+			regressions = append(regressions, fmt.Sprintf("%s: %s no longer found (%s)", r.Algorithm, r.Element, r.Error))
+			reported[k] = true
+		} else if b.Valid && !r.Valid {
+			regressions = append(regressions, fmt.Sprintf("%s: %s no longer valid (%s)", r.Algorithm, r.Element, r.Error))
+			reported[k] = true
+		}
+	}
+
+	oldSummary := make(map[string]benchSummary)
+	for _, s := range summarizeBench(baseline) {
+		oldSummary[s.Algorithm] = s
+	}
+	for _, s := range summarizeBench(rows) {
+		old, ok := oldSummary[s.Algorithm]
+		if !ok || old.MedianRuntime == 0 {
+			continue
+		}
+		ratio := float64(s.MedianRuntime) / float64(old.MedianRuntime)
+		if ratio > slowdown {
+			regressions = append(regressions, fmt.Sprintf("%s: median runtime %s -> %s (x%.2f)", s.Algorithm, old.MedianRuntime, s.MedianRuntime, ratio))
+		}
+	}
+	return regressions
+}
+
+func readBenchCSV(path string) ([]benchRow, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	var rows []benchRow
+	for i, rec := range records {
+		if i == 0 {
+			continue
+		}
+		r, err := parseBenchRecord(rec)
+		if err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", path, i+1, err)
+		}
+		rows = append(rows, r)
+	}
+	return rows, nil
+}
+
+func cmdBench(args []string) int {
+	fs, data, verbose := newCLIFlags("bench")
+	algorithms := fs.String("algorithms", "all", "comma-separated algorithms to run, or all")
+	sample := fs.Int("sample", 0, "benchmark a random sample of N elements, 0 for all")
+	seed := fs.Int64("seed", 1, "seed of the element sample")
+	elements := fs.String("elements", "", "comma-separated elements to benchmark instead of a sample")
+	repeat := fs.Int("repeat", 1, "runs per algorithm and element")
+	count := fs.Int("count", 3, "recipes requested by the multiple-path algorithms")
+	timeout := fs.Duration("timeout", 10*time.Second, "give up on a single run after this long")
+	csvPath := fs.String("csv", "", "write every run to this CSV file")
+	baselinePath := fs.String("baseline", "", "CSV of an earlier run to compare against")
+	slowdown := fs.Float64("slowdown", 1.5, "median runtime ratio reported as a regression")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := setupCLI(*data, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	var algs []benchAlgorithm
+	for _, alg := range benchAlgorithms(*count) {
+		if *algorithms == "all" || containsString(splitList(*algorithms), alg.Name) {
+			algs = append(algs, alg)
+		}
+	}
+	if len(algs) == 0 {
+		fmt.Fprintln(os.Stderr, "error: no algorithm matches", *algorithms)
+		return 2
+	}
+
+	targets := splitList(strings.ToLower(*elements))
+	if len(targets) == 0 {
+		targets = elementNames()
+		if *sample > 0 && *sample < len(targets) {
+			rng := rand.New(rand.NewSource(*seed))
+			rng.Shuffle(len(targets), func(i, j int) { targets[i], targets[j] = targets[j], targets[i] })
+			targets = targets[:*sample]
+			sort.Strings(targets)
+		}
+	}
+
+	var csvWriter *csv.Writer
+	if *csvPath != "" {
+		f, err := os.Create(*csvPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		defer f.Close()
+		csvWriter = csv.NewWriter(f)
+		defer csvWriter.Flush()
+		_ = csvWriter.Write(benchHeader)
+	}
+
+	// Ctrl-C cancels the running search and stops the benchmark
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var rows []benchRow
+	total := len(targets) * len(algs) * *repeat
+	for _, element := range targets {
+		for _, alg := range algs {
+			for i := 1; i <= *repeat && ctx.Err() == nil; i++ {
+				row := runBench(ctx, alg, element, i, *timeout)
+				if ctx.Err() != nil {
+					break
+				}
+				rows = append(rows, row)
+				if csvWriter != nil {
+					_ = csvWriter.Write(row.record())
+				}
+				fmt.Fprintf(os.Stderr, "\r[%d/%d] %-20s %-30s", len(rows), total, alg.Name, element)
+			}
+		}
+	}
+	fmt.Fprintln(os.Stderr)
+
+	printBenchSummary(cliOut, summarizeBench(rows))
+
+	if *baselinePath != "" {
+		baseline, err := readBenchCSV(*baselinePath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		regressions := compareBench(baseline, rows, *slowdown)
+		fmt.Fprintf(cliOut, "\nCompared with %s: %d regressions\n", *baselinePath, len(regressions))
+		for _, r := range regressions {
+			fmt.Fprintln(cliOut, "REGRESSION", r)
+		}
+		if len(regressions) > 0 {
+			return 1
+		}
+	}
+	return 0
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -1,12 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 )
 
-func bfsBidirectionalPath(target string) ([]string, bool, time.Duration, int) {
+func bfsBidirectionalPath(ctx context.Context, target string) ([]string, bool, time.Duration, int) {
 	startTime := time.Now()
 	target = strings.ToLower(target)
 
@@ -36,6 +37,9 @@ func bfsBidirectionalPath(target string) ([]string, bool, time.Duration, int) {
 	iteration := 0
 
 	for {
+		if ctx.Err() != nil {
+			return nil, false, time.Since(startTime), nodesVisited
+		}
 		iteration++
 		fmt.Printf("[DEBUG] Iteration %d\n", iteration)
 
@@ -1,15 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"reflect"
-	"runtime"
 	"strings"
 	"sync"
 	"time"
 )
 
-func bfsMultiplePaths(target string, maxPaths int) ([][]string, bool,time.Duration, int) {
+func bfsMultiplePaths(ctx context.Context, target string, maxPaths int) ([][]string, bool,time.Duration, int) {
     target = strings.ToLower(target)
     start := time.Now()
     if baseElements[target] {
@@ -42,7 +42,7 @@ func bfsMultiplePaths(target string, maxPaths int) ([][]string, bool,time.Durati
     elementInfoMu.Unlock()
 
     // BFS level per level
-    for len(queue) > 0 && !found {
+    for len(queue) > 0 && !found && ctx.Err() == nil {
         levelSize := len(queue)
         workCh := make(chan string, levelSize)
         
@@ -54,7 +54,7 @@ func bfsMultiplePaths(target string, maxPaths int) ([][]string, bool,time.Durati
         queue = []string{}
         var wg sync.WaitGroup
 
-        for i := 0; i < runtime.NumCPU(); i++ {
+        for i := 0; i < numWorkers; i++ {
             wg.Add(1)
             go func() {
                 defer wg.Done()
@@ -90,6 +90,9 @@ func bfsMultiplePaths(target string, maxPaths int) ([][]string, bool,time.Durati
 
                     // Proses kombinasi
                     for _, other := range keys {
+                        if ctx.Err() != nil {
+                            return
+                        }
                         mutex.RLock()
                         for resultElement, recipes := range recipesMap {
                             for _, ingredients := range recipes {
@@ -155,6 +158,9 @@ func bfsMultiplePaths(target string, maxPaths int) ([][]string, bool,time.Durati
 	// Rekonstruksi path dari predecessor
 	var buildPaths func(element string) [][]string
 	buildPaths = func(element string) [][]string {
+		if ctx.Err() != nil {
+			return nil
+		}
 		if elementInfo[element].level == 0 {
 			return [][]string{{}}
 		}
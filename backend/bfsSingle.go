@@ -1,13 +1,14 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 )
 
 // BFS Single Path dengan queue yang benar
-func bfsSinglePath(target string) ([]string, bool, time.Duration, int) {
+func bfsSinglePath(ctx context.Context, target string) ([]string, bool, time.Duration, int) {
 	startTime := time.Now()
 	target = strings.ToLower(target)
 
@@ -38,6 +39,9 @@ func bfsSinglePath(target string) ([]string, bool, time.Duration, int) {
 
 			// Coba kombinasi dengan semua elemen yang sudah ditemukan
 			for other := range discovered {
+				if ctx.Err() != nil {
+					return nil, false, time.Since(startTime), nodesVisited
+				}
 				// Cek kombinasi current + other
 				if result, exists := getCombinationResult(current, other); exists {
 					resultTier := tierMap[result]
@@ -0,0 +1,653 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// cliCommands are the subcommands of the binary, anything else starts the server.
+// Build it as the arachemy CLI with: go build -o arachemy .
+var cliCommands = map[string]func(args []string) int{
+	"serve":     cmdServe,
+	"find":      cmdFind,
+	"elements":  cmdElements,
+	"validate":  cmdValidate,
+	"repl":      cmdRepl,
+	"bench":     cmdBench,
+	"scrape":    cmdScrape,
+	"overrides": cmdOverrides,
+	"images":    cmdImages,
+}
+
+// cliOut is where commands print their results; os.Stdout is redirected
+// while the solvers run because they print debug output there
+var cliOut io.Writer = os.Stdout
+
+func cmdServe(args []string) int {
+	runServer(args)
+	return 0
+}
+
+// newCLIFlags returns a flag set with the options every command shares
+func newCLIFlags(name string) (*flag.FlagSet, *string, *bool) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	data := fs.String("data", "", "recipes.json to use (default from config)")
+	verbose := fs.Bool("v", false, "show solver debug output on stderr")
+	return fs, data, verbose
+}
+
+// setupCLI loads the config and dataset and silences the solver debug output
+func setupCLI(data string, verbose bool) error {
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		return err
+	}
+	if data != "" {
+		cfg.DataPath = data
+	}
+	applyConfig(cfg)
+
+	if verbose {
+		os.Stdout = os.Stderr
+	} else if devNull, err := os.Open(os.DevNull); err == nil {
+		os.Stdout = devNull
+	}
+	return loadDataset(cfg.DataPath)
+}
+
+func cmdFind(args []string) int {
+	fs, data, verbose := newCLIFlags("find")
+	target := fs.String("target", "", "element to make")
+	method := fs.String("method", "bfs", strings.Join(findMethods, ", "))
+	count := fs.Int("count", 1, "number of recipes")
+	bidirectional := fs.Bool("bidirectional", false, "use bidirectional search (count 1 only)")
+	packs := fs.String("packs", "", "comma-separated content packs to search, e.g. base,myths (default from config)")
+	optimize := fs.String("optimize", "", "objective of the optimal methods: "+strings.Join(optimizeObjectives, ", ")+" (default steps)")
+	weights := fs.String("weights", "", "element:weight list for -optimize weighted, e.g. lava:5,human:2")
+	maxDepth := fs.String("max-depth", "", "deepest limit of -method iddfs (default from config)")
+	format := fs.String("format", "text", "output format: text, json or tree")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *target == "" && fs.NArg() > 0 {
+		*target = strings.Join(fs.Args(), " ")
+	}
+
+	bidir := ""
+	if *bidirectional {
+		bidir = "true"
+	}
+	req, err := parseFindRequest(*target, *method, fmt.Sprint(*count), bidir)
+	if err == nil {
+		req.Objective, err = parseObjective(req.Method, *optimize, *weights)
+	}
+	if err == nil {
+		req.MaxDepth, err = parseMaxDepth(req.Method, *maxDepth)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	if err := setupCLI(*data, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if *packs != "" {
+		if req.Packs, err = parsePacks(*packs); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 2
+		}
+	}
+
+	result, err := findRecipes(context.Background(), req, nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	switch *format {
+	case "json":
+		printFindJSON(req, result)
+	case "tree":
+		for i, p := range result.Paths {
+			fmt.Fprintf(cliOut, "Path %d (%s)\n", i+1, pathSummary(p))
+			printRecipeTree(buildRecipeTree(req.Target, p.Steps), "", true, true)
+		}
+	default:
+		for i, p := range result.Paths {
+			fmt.Fprintf(cliOut, "Path %d (%s)\n", i+1, pathSummary(p))
+			for j, step := range p.Steps {
+				fmt.Fprintf(cliOut, "  %d. %s\n", j+1, step)
+			}
+		}
+	}
+	if !result.Found {
+		fmt.Fprintf(os.Stderr, "no recipe found for %s\n", req.Target)
+		return 1
+	}
+	return 0
+}
+
+// pathSummary is the runtime, nodes visited and cost of a path for text output
+func pathSummary(p PathResult) string {
+	s := fmt.Sprintf("%s, %d nodes visited", p.Runtime, p.NodesVisited)
+	if p.Cost != nil {
+		s += fmt.Sprintf(", %s %s", p.Objective, strconv.FormatFloat(*p.Cost, 'f', -1, 64))
+	}
+	if len(p.NodesByDepth) > 0 {
+		s += fmt.Sprintf(", depth %d", p.NodesByDepth[len(p.NodesByDepth)-1].Depth)
+	}
+	if p.Gap != nil && *p.Gap > 0 {
+		s += fmt.Sprintf(", up to %s above optimal", strconv.FormatFloat(*p.Gap, 'f', -1, 64))
+	}
+	return s
+}
+
+func printFindJSON(req FindRequest, result *FindResult) {
+	type pathJSON struct {
+		Steps        []string     `json:"steps"`
+		Runtime      string       `json:"runtime"`
+		NodesVisited int          `json:"nodesVisited"`
+		Cost         *float64     `json:"cost,omitempty"`
+		Gap          *float64     `json:"gap,omitempty"`
+		Objective    string       `json:"objective,omitempty"`
+		NodesByDepth []DepthNodes `json:"nodesByDepth,omitempty"`
+	}
+	out := struct {
+		Target       string     `json:"target"`
+		Method       string     `json:"method"`
+		Found        bool       `json:"found"`
+		Runtime      string     `json:"runtime"`
+		NodesVisited int        `json:"nodesVisited"`
+		Paths        []pathJSON `json:"paths"`
+	}{
+		Target:       req.Target,
+		Method:       req.Method,
+		Found:        result.Found,
+		Runtime:      result.Runtime.String(),
+		NodesVisited: result.NodesVisited,
+		Paths:        []pathJSON{},
+	}
+	for _, p := range result.Paths {
+		out.Paths = append(out.Paths, pathJSON{Steps: p.Steps, Runtime: p.Runtime.String(), NodesVisited: p.NodesVisited, Cost: p.Cost, Gap: p.Gap, Objective: p.Objective, NodesByDepth: p.NodesByDepth})
+	}
+	printJSON(out)
+}
+
+// printRecipeTree draws node and its ingredients with box-drawing branches
+func printRecipeTree(node *recipeTree, prefix string, last, root bool) {
+	if root {
+		fmt.Fprintln(cliOut, node.Element)
+	} else {
+		branch := "├── "
+		if last {
+			branch = "└── "
+		}
+		fmt.Fprintln(cliOut, prefix+branch+node.Element)
+		if last {
+			prefix += "    "
+		} else {
+			prefix += "│   "
+		}
+	}
+	for i, child := range node.Children {
+		printRecipeTree(child, prefix, i == len(node.Children)-1, false)
+	}
+}
+
+func cmdElements(args []string) int {
+	fs, data, verbose := newCLIFlags("elements")
+	search := fs.String("search", "", "only elements whose name contains this text")
+	tier := fs.Int("tier", -1, "only elements of this tier")
+	format := fs.String("format", "text", "output format: text or json")
+	metrics := fs.Bool("metrics", false, "show the number of recipe trees of the named element")
+	packs := fs.String("packs", "", "comma-separated content packs for -metrics (default from config)")
+	exclude := fs.String("exclude", "", "comma-separated elements -metrics must not use")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := setupCLI(*data, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	if *metrics {
+		if fs.NArg() == 0 {
+			fmt.Fprintln(os.Stderr, "error: -metrics needs an element name")
+			return 2
+		}
+		var packList []string
+		if *packs != "" {
+			var err error
+			if packList, err = parsePacks(*packs); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				return 2
+			}
+		}
+		acquirePacks(packList)
+		m, err := elementMetrics(strings.Join(fs.Args(), " "), splitList(*exclude))
+		releasePacks()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		if *format == "json" {
+			printJSON(m)
+			return 0
+		}
+		fmt.Fprintf(cliOut, "%s (tier %d, packs %s)\n", m.Name, m.Tier, strings.Join(m.Packs, ","))
+		if len(m.Exclude) > 0 {
+			fmt.Fprintf(cliOut, "Excluding: %s\n", strings.Join(m.Exclude, ", "))
+		}
+		fmt.Fprintf(cliOut, "Recipes: %d\n", m.Recipes)
+		fmt.Fprintf(cliOut, "Recipe trees: %s (%d digits)\n", m.RecipeTrees, m.RecipeTreesDigits)
+		return 0
+	}
+
+	// A name shows the details of that one element
+	if fs.NArg() > 0 {
+		name := strings.Join(fs.Args(), " ")
+		info, ok := lookupElement(name)
+		if !ok {
+			fmt.Fprintf(os.Stderr, "error: unknown element %s\n", name)
+			return 1
+		}
+		if *format == "json" {
+			printJSON(info)
+			return 0
+		}
+		fmt.Fprintf(cliOut, "%s (tier %d)\n", info.Name, info.Tier)
+		fmt.Fprintf(cliOut, "Recipes (%d):\n", len(info.Recipes))
+		for _, r := range info.Recipes {
+			fmt.Fprintf(cliOut, "  %s + %s\n", r[0], r[1])
+		}
+		fmt.Fprintf(cliOut, "Used in (%d): %s\n", len(info.UsedIn), strings.Join(info.UsedIn, ", "))
+		return 0
+	}
+
+	var tierFilter *int
+	if *tier >= 0 {
+		tierFilter = tier
+	}
+	var matches []ElementInfo
+	for _, info := range listElements("", tierFilter) {
+		if strings.Contains(info.Name, strings.ToLower(*search)) {
+			matches = append(matches, info)
+		}
+	}
+	if *format == "json" {
+		printJSON(matches)
+		return 0
+	}
+	for _, info := range matches {
+		fmt.Fprintf(cliOut, "%-30s tier %-3d %d recipes\n", info.Name, info.Tier, len(info.Recipes))
+	}
+	return 0
+}
+
+func cmdValidate(args []string) int {
+	fs, data, _ := newCLIFlags("validate")
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	path := cfg.DataPath
+	if *data != "" {
+		path = *data
+	}
+	os.Stdout = os.Stderr
+	recipes, err := loadRecipes(path)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	report := validateDataset(recipes)
+	if *format == "json" {
+		printJSON(report)
+	} else {
+		fmt.Fprintf(cliOut, "%s: %d recipes, %d elements\n", path, report.Recipes, report.Elements)
+		for _, e := range report.Errors {
+			fmt.Fprintln(cliOut, "ERROR  ", e)
+		}
+		for _, w := range report.Warnings {
+			fmt.Fprintln(cliOut, "WARN   ", w)
+		}
+		if len(report.Unreachable) > 0 {
+			fmt.Fprintf(cliOut, "Unreachable from base elements (%d): %s\n", len(report.Unreachable), strings.Join(report.Unreachable, ", "))
+		}
+		fmt.Fprintf(cliOut, "%d errors, %d warnings\n", len(report.Errors), len(report.Warnings))
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
+
+// cmdOverrides applies the overrides file to the dataset and prints which
+// patches applied, were no-ops or conflict. It fails on conflicts.
+func cmdOverrides(args []string) int {
+	fs, data, verbose := newCLIFlags("overrides")
+	file := fs.String("file", "", "overrides file to apply (default from config)")
+	format := fs.String("format", "text", "output format: text or json")
+	out := fs.String("out", "", "also write the patched recipes to this file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	if *data != "" {
+		cfg.DataPath = *data
+	}
+	if *file != "" {
+		cfg.OverridesPath = *file
+	}
+	applyConfig(cfg)
+	if *verbose {
+		os.Stdout = os.Stderr
+	} else if devNull, err := os.Open(os.DevNull); err == nil {
+		os.Stdout = devNull
+	}
+
+	if _, err := loadOverrides(cfg.OverridesPath); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	patched, err := loadPatchedRecipes(cfg.DataPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	recipes, report := patched.recipes, patched.report
+	if *format == "json" {
+		printJSON(report)
+	} else {
+		for _, r := range report.Results {
+			line := fmt.Sprintf("%-9s %s", strings.ToUpper(r.Status), r.Patch)
+			if r.Detail != "" {
+				line += " (" + r.Detail + ")"
+			}
+			fmt.Fprintln(cliOut, line)
+		}
+		fmt.Fprintf(cliOut, "%s: %d applied, %d no-op, %d conflicts\n", report.File, report.Applied, report.NoOp, report.Conflicts)
+	}
+	if *out != "" {
+		jsonBytes, err := json.Marshal(recipes)
+		if err == nil {
+			err = writeFileAtomic(*out, jsonBytes)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+	}
+	if report.Conflicts > 0 {
+		return 1
+	}
+	return 0
+}
+
+// cmdImages reports the elements without an icon and the icons without an
+// element in the image store. With --map-dir it maps a folder of *_2.svg
+// icons instead and writes mapped_elements.json.
+func cmdImages(args []string) int {
+	fs, data, verbose := newCLIFlags("images")
+	mapDir := fs.String("map-dir", "", "map this folder of *_2.svg icons instead of checking the image store")
+	out := fs.String("out", "", "mapped_elements.json written by --map-dir (default from config)")
+	format := fs.String("format", "text", "output format: text or json")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := setupCLI(*data, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	mutex.RLock()
+	elements := datasetElements(loadedRecipes)
+	stored := storedImages
+	mutex.RUnlock()
+	var report ImageReport
+	if *mapDir != "" {
+		mapping, r, err := mapImageDir(elements, *mapDir)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		path := appConfig.ImageMapPath
+		if *out != "" {
+			path = *out
+		}
+		if err := saveImageMap(path, mapping); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "mapped %d elements into %s\n", len(mapping), path)
+		report = r
+	} else {
+		report = checkImages(elements, appConfig.ImageStore, stored)
+	}
+
+	if *format == "json" {
+		printJSON(report)
+		return 0
+	}
+	fmt.Fprintf(cliOut, "%d elements, %d icons\n", report.Elements, report.Icons)
+	if len(report.Missing) > 0 {
+		fmt.Fprintf(cliOut, "Missing icons (%d): %s\n", len(report.Missing), strings.Join(report.Missing, ", "))
+	}
+	for _, o := range report.Orphans {
+		fmt.Fprintln(cliOut, "ORPHAN ", o)
+	}
+	return 0
+}
+
+// cmdScrape parses the elements page, fetched from the wiki or read from a
+// saved copy, and prints or saves the recipes. With -expect it compares the
+// result with a JSON fixture instead.
+func cmdScrape(args []string) int {
+	fs := flag.NewFlagSet("scrape", flag.ContinueOnError)
+	source := fs.String("source", DefaultSource, "parser of the page: "+strings.Join(parserNames(), ", "))
+	fromFile := fs.String("from-file", "", "parse this saved HTML page instead of fetching the wiki")
+	out := fs.String("out", "", "write the recipes to this file (- for stdout, default stdout)")
+	expect := fs.String("expect", "", "compare the recipes with this JSON file and fail on any difference")
+	deep := fs.Bool("deep", false, "also crawl every element page (uses the cache dir)")
+	elementsOut := fs.String("elements-out", "", "write the deep crawl to this file (default from config)")
+	images := fs.Bool("images", false, "also download the icons found by the deep crawl into the image store (implies --deep)")
+	force := fs.Bool("force", false, "write --out even if it fails the size checks")
+	elementPage := fs.String("element-page", "", "only parse this saved element page and print what the deep crawl reads from it")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	cfg, err := loadConfig(nil)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	applyConfig(cfg)
+	os.Stdout = os.Stderr
+	parser, err := lookupParser(*source)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 2
+	}
+	if *images && parser.Name() != DefaultSource {
+		fmt.Fprintln(os.Stderr, "error: --images only works with the default source", DefaultSource)
+		return 2
+	}
+
+	if *elementPage != "" {
+		f, err := os.Open(*elementPage)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		defer f.Close()
+		d, err := parseElementPage(f)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		printJSON(d)
+		return 0
+	}
+
+	var recipes []RecipeType
+	var report *LayoutReport
+	if *fromFile != "" {
+		f, err := os.Open(*fromFile)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		recipes, report, err = parser.Parse(f)
+		f.Close()
+		printLayoutReport(report)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+	} else {
+		recipes, report, err = scrapeElements(context.Background(), parser.URL(), parser, nil)
+		printLayoutReport(report)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+	}
+	fmt.Fprintf(os.Stderr, "parsed %d recipes\n", len(recipes))
+
+	if *deep || *images {
+		job := &ScrapeJob{}
+		details := crawlElementPages(context.Background(), parser.URL(), report.Links, job)
+		for _, e := range job.Errors {
+			fmt.Fprintln(os.Stderr, "CRAWL ERROR  ", e)
+		}
+		for _, w := range checkUsedToCreate(details, recipes) {
+			fmt.Fprintln(os.Stderr, "CRAWL WARN   ", w)
+		}
+		path := sourceElementsPath(parser.Name())
+		if *elementsOut != "" {
+			path = *elementsOut
+		}
+		if err := saveElementDetails(path, details); err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "crawled %d element pages (%d fetched) into %s\n", len(details), job.PagesFetched, path)
+
+		if *images {
+			job.Errors = nil
+			index := downloadElementImages(context.Background(), cfg.ImageStore, details, job)
+			for _, e := range job.Errors {
+				fmt.Fprintln(os.Stderr, "IMAGE ERROR  ", e)
+			}
+			if err := saveImageIndex(cfg.ImageStore, index); err != nil {
+				fmt.Fprintln(os.Stderr, "error:", err)
+				return 1
+			}
+			fmt.Fprintf(os.Stderr, "%d icons in %s\n", len(index), cfg.ImageStore)
+		}
+	}
+
+	if *expect != "" {
+		data, err := os.ReadFile(*expect)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		var want []RecipeType
+		if err := json.Unmarshal(data, &want); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s: %v\n", *expect, err)
+			return 1
+		}
+		diffs := diffRecipes(want, recipes)
+		for _, d := range diffs {
+			fmt.Fprintln(cliOut, d)
+		}
+		if len(diffs) > 0 {
+			fmt.Fprintf(cliOut, "%d differences from %s\n", len(diffs), *expect)
+			return 1
+		}
+		fmt.Fprintf(cliOut, "recipes match %s\n", *expect)
+		return 0
+	}
+
+	if *out != "" && *out != "-" {
+		version, err := saveRecipes(*out, recipes, *force)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "saved to %s (version %s)\n", *out, version)
+		return 0
+	}
+	printJSON(recipes)
+	return 0
+}
+
+// printLayoutReport shows on stderr which section became which tier
+func printLayoutReport(report *LayoutReport) {
+	if report == nil {
+		return
+	}
+	for _, section := range report.Sections {
+		switch {
+		case section.Skipped:
+			fmt.Fprintf(os.Stderr, "  %-30s skipped  (%d tables)\n", section.Heading, section.Tables)
+		case section.Inferred:
+			fmt.Fprintf(os.Stderr, "  %-30s inferred (%d tables, %d recipes)\n", section.Heading, section.Tables, section.Recipes)
+		default:
+			fmt.Fprintf(os.Stderr, "  %-30s tier %-3d (%d tables, %d recipes)\n", section.Heading, section.Tier, section.Tables, section.Recipes)
+		}
+	}
+	for _, e := range report.Errors {
+		fmt.Fprintln(os.Stderr, "LAYOUT ERROR ", e)
+	}
+	for _, w := range report.Warnings {
+		fmt.Fprintln(os.Stderr, "LAYOUT WARN  ", w)
+	}
+}
+
+// diffRecipes lists the recipes missing from got or not expected in it
+func diffRecipes(want, got []RecipeType) []string {
+	count := make(map[RecipeType]int)
+	for _, r := range want {
+		count[r]++
+	}
+	var diffs []string
+	for _, r := range got {
+		if count[r] == 0 {
+			diffs = append(diffs, fmt.Sprintf("unexpected: %s + %s = %s (tier %d)", r.Ingredient1, r.Ingredient2, r.Element, r.Type))
+			continue
+		}
+		count[r]--
+	}
+	for _, r := range want {
+		if count[r] > 0 {
+			count[r]--
+			diffs = append(diffs, fmt.Sprintf("missing:    %s + %s = %s (tier %d)", r.Ingredient1, r.Ingredient2, r.Element, r.Type))
+		}
+	}
+	return diffs
+}
+
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(cliOut)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
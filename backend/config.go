@@ -0,0 +1,468 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds every knob that can be changed per deployment.
+// Values are resolved in order: defaults, config file, env vars, flags.
+type Config struct {
+	Port      string `json:"port" yaml:"port" toml:"port"`
+	GRPCPort  string `json:"grpcPort" yaml:"grpcPort" toml:"grpcPort"`
+	DataPath  string `json:"dataPath" yaml:"dataPath" toml:"dataPath"`
+	ScrapeURL string `json:"scrapeURL" yaml:"scrapeURL" toml:"scrapeURL"`
+
+	MinElements    int     `json:"minElements" yaml:"minElements" toml:"minElements"`
+	MaxShrinkRatio float64 `json:"maxShrinkRatio" yaml:"maxShrinkRatio" toml:"maxShrinkRatio"`
+
+	ElementsPath     string `json:"elementsPath" yaml:"elementsPath" toml:"elementsPath"`
+	OverridesPath    string `json:"overridesPath" yaml:"overridesPath" toml:"overridesPath"`
+	CacheDir         string `json:"cacheDir" yaml:"cacheDir" toml:"cacheDir"`
+	CrawlDelayMillis int    `json:"crawlDelayMillis" yaml:"crawlDelayMillis" toml:"crawlDelayMillis"`
+	CrawlParallelism int    `json:"crawlParallelism" yaml:"crawlParallelism" toml:"crawlParallelism"`
+	HTTPCache        bool   `json:"httpCache" yaml:"httpCache" toml:"httpCache"`
+	Retries          int    `json:"retries" yaml:"retries" toml:"retries"`
+	RetryBaseMillis  int    `json:"retryBaseMillis" yaml:"retryBaseMillis" toml:"retryBaseMillis"`
+	RetryMaxMillis   int    `json:"retryMaxMillis" yaml:"retryMaxMillis" toml:"retryMaxMillis"`
+
+	ImageMapPath string `json:"imageMapPath" yaml:"imageMapPath" toml:"imageMapPath"`
+	ImageBaseURL string `json:"imageBaseURL" yaml:"imageBaseURL" toml:"imageBaseURL"`
+	ImageStore   string `json:"imageStore" yaml:"imageStore" toml:"imageStore"`
+
+	CORSOrigins     []string `json:"corsOrigins" yaml:"corsOrigins" toml:"corsOrigins"`
+	MaxDepth        int      `json:"maxDepth" yaml:"maxDepth" toml:"maxDepth"`
+	MaxResults      int      `json:"maxResults" yaml:"maxResults" toml:"maxResults"`
+	MaxNumberRecipe int      `json:"maxNumberRecipe" yaml:"maxNumberRecipe" toml:"maxNumberRecipe"`
+	Workers         int      `json:"workers" yaml:"workers" toml:"workers"`
+	MaxPrints       int      `json:"maxPrints" yaml:"maxPrints" toml:"maxPrints"`
+
+	SearchTimeoutSeconds int `json:"searchTimeoutSeconds" yaml:"searchTimeoutSeconds" toml:"searchTimeoutSeconds"`
+	ExactMaxNodes        int `json:"exactMaxNodes" yaml:"exactMaxNodes" toml:"exactMaxNodes"`
+	ExactTimeoutMillis   int `json:"exactTimeoutMillis" yaml:"exactTimeoutMillis" toml:"exactTimeoutMillis"`
+	AStarMaxNodes        int `json:"astarMaxNodes" yaml:"astarMaxNodes" toml:"astarMaxNodes"`
+
+	Packs        []Pack   `json:"packs" yaml:"packs" toml:"packs"`
+	DefaultPacks []string `json:"defaultPacks" yaml:"defaultPacks" toml:"defaultPacks"`
+
+	APIKeys        []APIKey `json:"apiKeys" yaml:"apiKeys" toml:"apiKeys" secret:"true"`
+	APIKeysFile    string   `json:"apiKeysFile" yaml:"apiKeysFile" toml:"apiKeysFile"`
+	RequireReadKey bool     `json:"requireReadKey" yaml:"requireReadKey" toml:"requireReadKey"`
+}
+
+// configKey describes one setting that can come from an env var or a flag
+type configKey struct {
+	flag  string
+	env   string
+	usage string
+	set   func(c *Config, v string) error
+}
+
+var configKeys = []configKey{
+	{"port", "PORT", "HTTP listen port", func(c *Config, v string) error {
+		c.Port = v
+		return nil
+	}},
+	{"grpc-port", "ARACHEMY_GRPC_PORT", "gRPC listen port, empty to disable", func(c *Config, v string) error {
+		c.GRPCPort = v
+		return nil
+	}},
+	{"data", "ARACHEMY_DATA_PATH", "path to recipes.json", func(c *Config, v string) error {
+		c.DataPath = v
+		return nil
+	}},
+	{"image-map", "ARACHEMY_IMAGE_MAP", "mapped_elements.json written by arachemy images --map-dir", func(c *Config, v string) error {
+		c.ImageMapPath = v
+		return nil
+	}},
+	{"image-base-url", "ARACHEMY_IMAGE_BASE_URL", "URL prefix of element icons", func(c *Config, v string) error {
+		c.ImageBaseURL = v
+		return nil
+	}},
+	{"image-store", "ARACHEMY_IMAGE_STORE", "folder of the icons downloaded by the scraper", func(c *Config, v string) error {
+		c.ImageStore = v
+		return nil
+	}},
+	{"scrape-url", "ARACHEMY_SCRAPE_URL", "wiki page scraped by /scrape", func(c *Config, v string) error {
+		c.ScrapeURL = v
+		return nil
+	}},
+	{"min-elements", "ARACHEMY_MIN_ELEMENTS", "refuse to save a scraped dataset with fewer elements", func(c *Config, v string) error {
+		return setInt(&c.MinElements, v)
+	}},
+	{"max-shrink", "ARACHEMY_MAX_SHRINK", "refuse to save a scraped dataset smaller than the previous one by more than this ratio (0-1)", func(c *Config, v string) error {
+		return setFloat(&c.MaxShrinkRatio, v)
+	}},
+	{"elements", "ARACHEMY_ELEMENTS_PATH", "path to elements.json written by the deep crawl", func(c *Config, v string) error {
+		c.ElementsPath = v
+		return nil
+	}},
+	{"overrides", "ARACHEMY_OVERRIDES_PATH", "hand-made fixes applied on top of the dataset (.yaml, .toml or .json)", func(c *Config, v string) error {
+		c.OverridesPath = v
+		return nil
+	}},
+	{"cache-dir", "ARACHEMY_CACHE_DIR", "on-disk cache of crawled element pages", func(c *Config, v string) error {
+		c.CacheDir = v
+		return nil
+	}},
+	{"crawl-delay", "ARACHEMY_CRAWL_DELAY", "milliseconds between element page requests", func(c *Config, v string) error {
+		return setInt(&c.CrawlDelayMillis, v)
+	}},
+	{"crawl-parallelism", "ARACHEMY_CRAWL_PARALLELISM", "element pages fetched at the same time", func(c *Config, v string) error {
+		return setInt(&c.CrawlParallelism, v)
+	}},
+	{"http-cache", "ARACHEMY_HTTP_CACHE", "keep scraped pages in the cache dir and revalidate them", func(c *Config, v string) error {
+		return setBool(&c.HTTPCache, v)
+	}},
+	{"retries", "ARACHEMY_RETRIES", "retries of a scrape request after a transient error", func(c *Config, v string) error {
+		return setInt(&c.Retries, v)
+	}},
+	{"retry-base", "ARACHEMY_RETRY_BASE", "milliseconds before the first retry, doubled each time", func(c *Config, v string) error {
+		return setInt(&c.RetryBaseMillis, v)
+	}},
+	{"retry-max", "ARACHEMY_RETRY_MAX", "longest wait between retries in milliseconds, also the longest Retry-After honoured", func(c *Config, v string) error {
+		return setInt(&c.RetryMaxMillis, v)
+	}},
+	{"cors-origins", "ARACHEMY_CORS_ORIGINS", "comma-separated allowed origins, * for all", func(c *Config, v string) error {
+		c.CORSOrigins = splitList(v)
+		return nil
+	}},
+	{"max-depth", "ARACHEMY_MAX_DEPTH", "depth limit of the combinatorial DFS, and the default maxDepth of iddfs", func(c *Config, v string) error {
+		return setInt(&c.MaxDepth, v)
+	}},
+	{"max-results", "ARACHEMY_MAX_RESULTS", "default number of recipes per DFS job", func(c *Config, v string) error {
+		return setInt(&c.MaxResults, v)
+	}},
+	{"max-number-recipe", "ARACHEMY_MAX_NUMBER_RECIPE", "most recipes one /find request may ask for", func(c *Config, v string) error {
+		return setInt(&c.MaxNumberRecipe, v)
+	}},
+	{"workers", "ARACHEMY_WORKERS", "number of search workers", func(c *Config, v string) error {
+		return setInt(&c.Workers, v)
+	}},
+	{"max-prints", "ARACHEMY_MAX_PRINTS", "limit of DFS debug lines", func(c *Config, v string) error {
+		return setInt(&c.MaxPrints, v)
+	}},
+	{"search-timeout", "ARACHEMY_SEARCH_TIMEOUT", "seconds before /find gives up, 0 for no limit", func(c *Config, v string) error {
+		return setInt(&c.SearchTimeoutSeconds, v)
+	}},
+	{"exact-max-nodes", "ARACHEMY_EXACT_MAX_NODES", "states the exact solver may expand before returning its best recipe", func(c *Config, v string) error {
+		return setInt(&c.ExactMaxNodes, v)
+	}},
+	{"exact-timeout", "ARACHEMY_EXACT_TIMEOUT", "milliseconds the exact solver may search before returning its best recipe", func(c *Config, v string) error {
+		return setInt(&c.ExactTimeoutMillis, v)
+	}},
+	{"astar-max-nodes", "ARACHEMY_ASTAR_MAX_NODES", "states A* may expand before giving up", func(c *Config, v string) error {
+		return setInt(&c.AStarMaxNodes, v)
+	}},
+	{"default-packs", "ARACHEMY_DEFAULT_PACKS", "comma-separated packs searched when a request names none", func(c *Config, v string) error {
+		c.DefaultPacks = splitList(strings.ToLower(v))
+		return nil
+	}},
+	{"api-keys", "ARACHEMY_API_KEYS", "comma-separated role:key[:name] entries", func(c *Config, v string) error {
+		c.APIKeys = nil
+		for _, entry := range splitList(v) {
+			k, err := parseAPIKey(entry)
+			if err != nil {
+				return err
+			}
+			c.APIKeys = append(c.APIKeys, k)
+		}
+		return nil
+	}},
+	{"api-keys-file", "ARACHEMY_API_KEYS_FILE", "file with one role:key[:name] per line", func(c *Config, v string) error {
+		c.APIKeysFile = v
+		return nil
+	}},
+	{"require-read-key", "ARACHEMY_REQUIRE_READ_KEY", "require an API key for read/search routes too", func(c *Config, v string) error {
+		return setBool(&c.RequireReadKey, v)
+	}},
+}
+
+// appConfig is the configuration the server is running with
+var appConfig = defaultConfig()
+
+func defaultConfig() *Config {
+	return &Config{
+		Port:      "8080",
+		GRPCPort:  "9090",
+		DataPath:  "data/recipes.json",
+		ScrapeURL: "https://little-alchemy.fandom.com/wiki/Elements_(Little_Alchemy_2)",
+
+		MinElements:    500,
+		MaxShrinkRatio: 0.1,
+
+		ElementsPath:     "data/elements.json",
+		OverridesPath:    "data/overrides.yaml",
+		CacheDir:         "data/cache",
+		CrawlDelayMillis: 1000,
+		CrawlParallelism: 2,
+		HTTPCache:        true,
+		Retries:          3,
+		RetryBaseMillis:  500,
+		RetryMaxMillis:   30000,
+
+		ImageMapPath: "../frontend/public/mapped_elements.json",
+		ImageBaseURL: "/images/",
+		ImageStore:   "data/images",
+
+		CORSOrigins:     []string{"*"},
+		MaxDepth:        19,
+		MaxResults:      3,
+		MaxNumberRecipe: 1000,
+		Workers:         runtime.NumCPU(),
+		MaxPrints:       200,
+
+		SearchTimeoutSeconds: 60,
+		ExactMaxNodes:        200000,
+		ExactTimeoutMillis:   5000,
+		AStarMaxNodes:        500000,
+
+		Packs:        defaultPacks(),
+		DefaultPacks: []string{BasePack},
+	}
+}
+
+// loadConfig builds the config from defaults, the optional config file
+// (-config or ARACHEMY_CONFIG), env vars and finally command-line flags
+func loadConfig(args []string) (*Config, error) {
+	fs := flag.NewFlagSet("arachemy", flag.ContinueOnError)
+	configPath := fs.String("config", os.Getenv("ARACHEMY_CONFIG"), "optional YAML or TOML config file")
+	flagValues := make(map[string]string)
+	for _, k := range configKeys {
+		name := k.flag
+		fs.Func(name, k.usage+" (env "+k.env+")", func(v string) error {
+			flagValues[name] = v
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := defaultConfig()
+	if *configPath != "" {
+		if err := cfg.loadFile(*configPath); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range configKeys {
+		if v, ok := os.LookupEnv(k.env); ok && v != "" {
+			if err := k.set(cfg, v); err != nil {
+				return nil, fmt.Errorf("env %s: %w", k.env, err)
+			}
+		}
+	}
+	for _, k := range configKeys {
+		if v, ok := flagValues[k.flag]; ok {
+			if err := k.set(cfg, v); err != nil {
+				return nil, fmt.Errorf("flag -%s: %w", k.flag, err)
+			}
+		}
+	}
+
+	if cfg.APIKeysFile != "" {
+		keys, err := loadAPIKeysFile(cfg.APIKeysFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.APIKeys = append(cfg.APIKeys, keys...)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile overlays the YAML or TOML file at path on top of c
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config %s: %w", path, err)
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, c)
+	case ".toml":
+		err = toml.Unmarshal(data, c)
+	default:
+		return fmt.Errorf("config %s: unsupported format, use .yaml or .toml", path)
+	}
+	if err != nil {
+		return fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return nil
+}
+
+// Validate reports the first invalid setting
+func (c *Config) Validate() error {
+	port, err := strconv.Atoi(c.Port)
+	if err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("invalid port %q", c.Port)
+	}
+	if c.GRPCPort != "" {
+		grpcPort, err := strconv.Atoi(c.GRPCPort)
+		if err != nil || grpcPort < 1 || grpcPort > 65535 {
+			return fmt.Errorf("invalid grpcPort %q", c.GRPCPort)
+		}
+		if c.GRPCPort == c.Port {
+			return fmt.Errorf("grpcPort must differ from port")
+		}
+	}
+	if c.DataPath == "" {
+		return fmt.Errorf("dataPath must not be empty")
+	}
+	if !strings.HasPrefix(c.ScrapeURL, "http://") && !strings.HasPrefix(c.ScrapeURL, "https://") {
+		return fmt.Errorf("invalid scrapeURL %q", c.ScrapeURL)
+	}
+	if c.MinElements < 0 {
+		return fmt.Errorf("minElements must not be negative")
+	}
+	if c.MaxShrinkRatio < 0 || c.MaxShrinkRatio > 1 {
+		return fmt.Errorf("maxShrinkRatio must be between 0 and 1")
+	}
+	if c.CrawlDelayMillis < 0 {
+		return fmt.Errorf("crawlDelayMillis must not be negative")
+	}
+	if c.CrawlParallelism < 1 {
+		return fmt.Errorf("crawlParallelism must be at least 1")
+	}
+	if c.Retries < 0 || c.RetryBaseMillis < 0 || c.RetryMaxMillis < c.RetryBaseMillis {
+		return fmt.Errorf("retries and retryBaseMillis must not be negative, retryMaxMillis must be at least retryBaseMillis")
+	}
+	if len(c.CORSOrigins) == 0 {
+		return fmt.Errorf("corsOrigins must not be empty")
+	}
+	for _, o := range c.CORSOrigins {
+		if o != "*" && !strings.HasPrefix(o, "http://") && !strings.HasPrefix(o, "https://") {
+			return fmt.Errorf("invalid CORS origin %q", o)
+		}
+	}
+	if c.MaxDepth < 1 {
+		return fmt.Errorf("maxDepth must be at least 1")
+	}
+	if c.MaxResults < 1 {
+		return fmt.Errorf("maxResults must be at least 1")
+	}
+	if c.MaxNumberRecipe < 1 {
+		return fmt.Errorf("maxNumberRecipe must be at least 1")
+	}
+	if c.Workers < 1 {
+		return fmt.Errorf("workers must be at least 1")
+	}
+	if c.MaxPrints < 0 {
+		return fmt.Errorf("maxPrints must not be negative")
+	}
+	if c.SearchTimeoutSeconds < 0 {
+		return fmt.Errorf("searchTimeoutSeconds must not be negative")
+	}
+	if c.ExactMaxNodes < 1 || c.ExactTimeoutMillis < 1 {
+		return fmt.Errorf("exactMaxNodes and exactTimeoutMillis must be at least 1")
+	}
+	if c.AStarMaxNodes < 1 {
+		return fmt.Errorf("astarMaxNodes must be at least 1")
+	}
+	packNames := map[string]bool{BasePack: true}
+	for i, p := range c.Packs {
+		if p.Name == "" || p.Name == BasePack || p.Name != strings.ToLower(p.Name) {
+			return fmt.Errorf("packs[%d]: name must be lowercase and not %q", i, BasePack)
+		}
+		if packNames[p.Name] {
+			return fmt.Errorf("packs[%d]: duplicate pack %q", i, p.Name)
+		}
+		packNames[p.Name] = true
+	}
+	for _, name := range c.DefaultPacks {
+		if !packNames[name] {
+			return fmt.Errorf("defaultPacks: unknown pack %q", name)
+		}
+	}
+	for i, k := range c.APIKeys {
+		if k.Key == "" {
+			return fmt.Errorf("apiKeys[%d]: key must not be empty", i)
+		}
+		if !validRole(k.Role) {
+			return fmt.Errorf("apiKeys[%d]: unknown role %q", i, k.Role)
+		}
+	}
+	return nil
+}
+
+// applyConfig copies the config into the package variables used by the solvers
+func applyConfig(c *Config) {
+	appConfig = c
+	maxDepth = c.MaxDepth
+	maxResults = c.MaxResults
+	numWorkers = c.Workers
+	maxPrints = c.MaxPrints
+}
+
+// Redacted returns the config as a JSON-ready map with every field
+// tagged `secret:"true"` masked
+func (c *Config) Redacted() map[string]interface{} {
+	raw, _ := json.Marshal(c)
+	out := make(map[string]interface{})
+	_ = json.Unmarshal(raw, &out)
+
+	t := reflect.TypeOf(*c)
+	v := reflect.ValueOf(*c)
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Tag.Get("secret") != "true" {
+			continue
+		}
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if v.Field(i).IsZero() {
+			continue
+		}
+		out[name] = "[REDACTED]"
+	}
+	return out
+}
+
+func setInt(dst *int, v string) error {
+	n, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil {
+		return fmt.Errorf("invalid number %q", v)
+	}
+	*dst = n
+	return nil
+}
+
+func setFloat(dst *float64, v string) error {
+	f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %q", v)
+	}
+	*dst = f
+	return nil
+}
+
+func setBool(dst *bool, v string) error {
+	b, err := strconv.ParseBool(strings.TrimSpace(v))
+	if err != nil {
+		return fmt.Errorf("invalid boolean %q", v)
+	}
+	*dst = b
+	return nil
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
+}
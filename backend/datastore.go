@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// backupPath is where the previous version of a dataset file is kept
+func backupPath(path string) string {
+	return path + ".bak"
+}
+
+// writeFileAtomic writes data to a temp file next to path and renames it
+// over path, so readers see either the old or the new file, never a partial one
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // no-op once renamed
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// backupFile copies the current file at path to backupPath(path), a
+// missing file has nothing to back up
+func backupFile(path string) error {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(backupPath(path), data)
+}
+
+// countElements returns the number of distinct element names in recipes
+func countElements(recipes []Recipe) int {
+	names := make(map[string]bool)
+	for _, r := range recipes {
+		names[strings.ToLower(r.Element)] = true
+		names[strings.ToLower(r.Ingredient1)] = true
+		names[strings.ToLower(r.Ingredient2)] = true
+	}
+	return len(names)
+}
+
+// checkDatasetWrite refuses a new dataset with fewer than
+// appConfig.MinElements elements, or that shrinks by more than
+// appConfig.MaxShrinkRatio compared with the file at path
+func checkDatasetWrite(path string, recipes []Recipe) error {
+	elements := countElements(recipes)
+	if elements < appConfig.MinElements {
+		return newAPIError(ErrDatasetRejected, fmt.Sprintf("%d elements, at least %d required", elements, appConfig.MinElements))
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		return nil // first version
+	}
+	previous, err := loadRecipes(path)
+	if err != nil {
+		fmt.Printf("[ERROR] Previous dataset %s unreadable, skipping shrink check: %v\n", path, err)
+		return nil
+	}
+	minRatio := 1 - appConfig.MaxShrinkRatio
+	if prev := len(previous); float64(len(recipes)) < float64(prev)*minRatio {
+		return newAPIError(ErrDatasetRejected, fmt.Sprintf("%d recipes, previous version has %d (max shrink %.0f%%)", len(recipes), prev, appConfig.MaxShrinkRatio*100))
+	}
+	if prev := countElements(previous); float64(elements) < float64(prev)*minRatio {
+		return newAPIError(ErrDatasetRejected, fmt.Sprintf("%d elements, previous version has %d (max shrink %.0f%%)", elements, prev, appConfig.MaxShrinkRatio*100))
+	}
+	return nil
+}
+
+// saveRecipes validates the scraped recipes, keeps the current file as a
+// backup and atomically replaces it. It returns the dataset version, a
+// short hash of the written file. force skips the validation.
+func saveRecipes(path string, recipes []RecipeType, force bool) (string, error) {
+	jsonBytes, err := json.Marshal(recipes)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal recipes to JSON: %w", err)
+	}
+	if !force {
+		var check []Recipe
+		if err := json.Unmarshal(jsonBytes, &check); err != nil {
+			return "", err
+		}
+		if err := checkDatasetWrite(path, check); err != nil {
+			return "", err
+		}
+	}
+	if err := backupFile(path); err != nil {
+		return "", fmt.Errorf("failed to back up %s: %w", path, err)
+	}
+	if err := writeFileAtomic(path, jsonBytes); err != nil {
+		return "", fmt.Errorf("failed to save recipes: %w", err)
+	}
+	return datasetVersion(jsonBytes), nil
+}
+
+// datasetVersion identifies the content of a recipes.json
+func datasetVersion(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+}
+
+// loadRecipesWithFallback loads file, or its backup when file is missing,
+// corrupt or empty
+func loadRecipesWithFallback(file string) ([]Recipe, error) {
+	recipes, err := loadRecipes(file)
+	if err == nil && len(recipes) == 0 {
+		err = fmt.Errorf("%s has no recipes", file)
+	}
+	if err == nil {
+		return recipes, nil
+	}
+	backup, backupErr := loadRecipes(backupPath(file))
+	if backupErr != nil || len(backup) == 0 {
+		return nil, err
+	}
+	fmt.Printf("[ERROR] Failed to load %s (%v), using backup %s\n", file, err, backupPath(file))
+	return backup, nil
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// useDatasetLimits sets the dataset write checks for the duration of the
+// test
+func useDatasetLimits(t *testing.T, minElements int, maxShrink float64) {
+	t.Helper()
+	old := appConfig
+	c := *appConfig
+	c.MinElements = minElements
+	c.MaxShrinkRatio = maxShrink
+	appConfig = &c
+	t.Cleanup(func() { appConfig = old })
+}
+
+// testDataset makes n recipes of n distinct elements from fire and water,
+// n+2 elements in all
+func testDataset(n int) []RecipeType {
+	recipes := make([]RecipeType, n)
+	for i := range recipes {
+		recipes[i] = RecipeType{Element: fmt.Sprintf("element%d", i), Ingredient1: "fire", Ingredient2: "water", Type: 1}
+	}
+	return recipes
+}
+
+func asRecipes(recipes []RecipeType) []Recipe {
+	out := make([]Recipe, len(recipes))
+	for i, r := range recipes {
+		out[i] = Recipe(r)
+	}
+	return out
+}
+
+// writeDataset writes recipes to path, bypassing the checks
+func writeDataset(t *testing.T, path string, recipes []RecipeType) {
+	t.Helper()
+	if _, err := saveRecipes(path, recipes, true); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func isRejected(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.Code == ErrDatasetRejected
+}
+
+func TestCheckDatasetWrite(t *testing.T) {
+	tests := []struct {
+		name     string
+		previous []RecipeType
+		corrupt  bool
+		next     int
+		rejected bool
+	}{
+		{name: "first version", next: 10},
+		{name: "too few elements", next: 5, rejected: true},
+		{name: "grows", previous: testDataset(20), next: 30},
+		{name: "shrinks within the ratio", previous: testDataset(100), next: 90},
+		{name: "shrinks too much", previous: testDataset(100), next: 89, rejected: true},
+		{name: "previous version unreadable", corrupt: true, next: 10},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useDatasetLimits(t, 10, 0.1)
+			path := filepath.Join(t.TempDir(), "recipes.json")
+			if tt.previous != nil {
+				writeDataset(t, path, tt.previous)
+			}
+			if tt.corrupt {
+				if err := os.WriteFile(path, []byte("{not json"), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			err := checkDatasetWrite(path, asRecipes(testDataset(tt.next)))
+			if tt.rejected && !isRejected(err) {
+				t.Errorf("want %s, got %v", ErrDatasetRejected, err)
+			}
+			if !tt.rejected && err != nil {
+				t.Errorf("want the write accepted, got %v", err)
+			}
+		})
+	}
+}
+
+func TestSaveRecipesKeepsBackup(t *testing.T) {
+	useDatasetLimits(t, 10, 0.1)
+	path := filepath.Join(t.TempDir(), "recipes.json")
+
+	first, err := saveRecipes(path, testDataset(20), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(backupPath(path)); !os.IsNotExist(err) {
+		t.Errorf("the first version has nothing to back up, stat says %v", err)
+	}
+	second, err := saveRecipes(path, testDataset(25), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Errorf("both versions are %s", first)
+	}
+	if got, err := loadRecipes(path); err != nil || len(got) != 25 {
+		t.Errorf("%s has %d recipes (%v), want 25", path, len(got), err)
+	}
+	if got, err := loadRecipes(backupPath(path)); err != nil || len(got) != 20 {
+		t.Errorf("backup has %d recipes (%v), want the 20 of the previous version", len(got), err)
+	}
+
+	if _, err := saveRecipes(path, testDataset(10), false); !isRejected(err) {
+		t.Fatalf("want the shrunk dataset rejected, got %v", err)
+	}
+	if got, _ := loadRecipes(path); len(got) != 25 {
+		t.Errorf("a rejected write changed %s to %d recipes", path, len(got))
+	}
+	if got, _ := loadRecipes(backupPath(path)); len(got) != 20 {
+		t.Errorf("a rejected write changed the backup to %d recipes", len(got))
+	}
+
+	if _, err := saveRecipes(path, testDataset(10), true); err != nil {
+		t.Fatalf("force should skip the checks: %v", err)
+	}
+	if got, _ := loadRecipes(backupPath(path)); len(got) != 25 {
+		t.Errorf("backup has %d recipes, want the 25 of the previous version", len(got))
+	}
+}
+
+func TestLoadRecipesWithFallback(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string // contents of recipes.json, empty for a missing file
+		backup  bool
+		want    int
+		wantErr bool
+	}{
+		{name: "good file", file: "good", backup: true, want: 30},
+		{name: "corrupt file", file: "{not json", backup: true, want: 20},
+		{name: "empty dataset", file: "[]", backup: true, want: 20},
+		{name: "missing file", backup: true, want: 20},
+		{name: "corrupt file without backup", file: "{not json", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "recipes.json")
+			if tt.backup {
+				writeDataset(t, path, testDataset(20))
+				if err := os.Rename(path, backupPath(path)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			switch tt.file {
+			case "":
+			case "good":
+				writeDataset(t, path, testDataset(30))
+			default:
+				if err := os.WriteFile(path, []byte(tt.file), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := loadRecipesWithFallback(path)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("want an error, got %d recipes", len(got))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != tt.want {
+				t.Errorf("loaded %d recipes, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
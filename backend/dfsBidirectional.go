@@ -1,12 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 )
 
-func dfsBidirectionalPath(target string) ([]string, bool, time.Duration, int) {
+func dfsBidirectionalPath(ctx context.Context, target string) ([]string, bool, time.Duration, int) {
 	target = strings.ToLower(target)
 	startTime := time.Now()
 	fmt.Printf("[DEBUG] Starting bidirectional DFS for target: %s\n", target)
@@ -33,7 +34,7 @@ func dfsBidirectionalPath(target string) ([]string, bool, time.Duration, int) {
 	meetingPointPathStart := []string{}
 	meetingPointPathGoal := []string{}
 	
-	for len(stackStart) > 0 && len(stackGoal) > 0 {
+	for len(stackStart) > 0 && len(stackGoal) > 0 && ctx.Err() == nil {
 		// Expand from start
 		currentStart := stackStart[len(stackStart)-1]
 		stackStart = stackStart[:len(stackStart)-1]
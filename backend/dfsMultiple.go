@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"runtime"
 	"strings"
 	"sync"
 	"time"
@@ -31,6 +33,7 @@ type JobResultDFS struct {
 var (
 	maxResults  = 3
 	maxDepth    = 19
+	numWorkers  = runtime.NumCPU()
 	resultMutex sync.Mutex
 )
 
@@ -45,9 +48,9 @@ func mapCopy(src map[string]bool) map[string]bool {
 	return dst
 }
 
-// DFS recursive with constraint
-func dfsCombinatorial(target string, visited map[string]bool, path []string, depth int) []ResultDFS {
-	if depth > maxDepth {
+// DFS recursive with constraint, returning up to limit paths
+func dfsCombinatorial(ctx context.Context, target string, visited map[string]bool, path []string, depth, limit int) []ResultDFS {
+	if depth > maxDepth || ctx.Err() != nil {
 		return nil
 	}
 
@@ -90,18 +93,14 @@ func dfsCombinatorial(target string, visited map[string]bool, path []string, dep
 		visited1 := mapCopy(visited)
 		visited2 := mapCopy(visited)
 
-		left := dfsCombinatorial(i1, visited1, path, depth+1)
-		right := dfsCombinatorial(i2, visited2, path, depth+1)
+		left := dfsCombinatorial(ctx, i1, visited1, path, depth+1, limit)
+		right := dfsCombinatorial(ctx, i2, visited2, path, depth+1, limit)
 
 		for _, l := range left {
 			for _, r := range right {
 				steps := append(append([]string{}, l.Steps...), r.Steps...)
 				step := fmt.Sprintf("%s + %s = %s", i1, i2, target)
 				steps = append(steps, step)
-				if printCount < maxPrints {
-					printCount++
-					// fmt.Printf("[DEBUG] Processing: %s + %s => %s (Depth: %d)\n", i1, i2, target, depth)
-				}
 
 				key := strings.Join(steps, "|")
 				if !uniquePaths[key] {
@@ -112,7 +111,7 @@ func dfsCombinatorial(target string, visited map[string]bool, path []string, dep
 						Runtime:      time.Since(startTime),
 					})
 					uniquePaths[key] = true
-					if len(results) >= maxResults {
+					if len(results) >= limit {
 						return results
 					}
 				}
@@ -124,10 +123,14 @@ func dfsCombinatorial(target string, visited map[string]bool, path []string, dep
 }
 
 // Worker goroutine
-func worker(id int, jobs <-chan Job, results chan<- JobResultDFS, wg *sync.WaitGroup) {
+func worker(ctx context.Context, id int, jobs <-chan Job, results chan<- JobResultDFS, wg *sync.WaitGroup) {
 	defer wg.Done()
 	for job := range jobs {
-		allResults := dfsCombinatorial(job.Target, make(map[string]bool), []string{}, 0)
+		limit := job.Limit
+		if limit == 0 {
+			limit = maxResults
+		}
+		allResults := dfsCombinatorial(ctx, job.Target, make(map[string]bool), []string{}, 0, limit)
 
 		for _, r := range allResults {
 			results <- JobResultDFS{
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+)
+
+// Every search has its own path limit, concurrent searches with other
+// counts do not cut it short
+func TestDFSWorkerPoolConcurrentCounts(t *testing.T) {
+	useGraph(t, smallGraph)
+	ctx := context.Background()
+	counts := []int{1, 2, 8}
+	want := make(map[int]int)
+	for _, count := range counts {
+		want[count] = len(dfsWorkerPool(ctx, "window", count, nil))
+	}
+	if want[8] <= want[1] {
+		t.Fatalf("want more paths with count 8 than with 1, got %d and %d", want[8], want[1])
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 30; i++ {
+		count := counts[i%len(counts)]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			paths := dfsWorkerPool(ctx, "window", count, nil)
+			if len(paths) != want[count] {
+				t.Errorf("count %d: got %d paths, alone it returns %d", count, len(paths), want[count])
+			}
+			for _, p := range paths {
+				if err := validatePath("window", p.Steps); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -2,13 +2,17 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 )
 
-func dfsSinglePath(element string, visited map[string]bool, trace []string, nodesVisited *int) ([]string, bool) {
+func dfsSinglePath(ctx context.Context, element string, visited map[string]bool, trace []string, nodesVisited *int) ([]string, bool) {
 	 *nodesVisited++
+	if ctx.Err() != nil {
+		return nil, false
+	}
 	if printCount < maxPrints {
 		fmt.Println("Processing:", strings.Join(trace, " -> "), "->", element)
 		printCount++
@@ -51,11 +55,11 @@ func dfsSinglePath(element string, visited map[string]bool, trace []string, node
 		}
 		newTrace := append([]string{}, trace...)
 		newTrace = append(newTrace, element)
-		leftSteps, ok1 := dfsSinglePath(ingr[0], copyMap(visited), newTrace, nodesVisited)
+		leftSteps, ok1 := dfsSinglePath(ctx, ingr[0], copyMap(visited), newTrace, nodesVisited)
 		if !ok1 {
 			continue
 		}
-		rightSteps, ok2 := dfsSinglePath(ingr[1], copyMap(visited), newTrace, nodesVisited)
+		rightSteps, ok2 := dfsSinglePath(ctx, ingr[1], copyMap(visited), newTrace, nodesVisited)
 		if !ok2 {
 			continue
 		}
@@ -67,10 +71,10 @@ func dfsSinglePath(element string, visited map[string]bool, trace []string, node
 	return nil, false
 }
 
-func DFSWrapper(target string) ([]string, bool, time.Duration, int) {
+func DFSWrapper(ctx context.Context, target string) ([]string, bool, time.Duration, int) {
     start := time.Now()
     nodesVisited := 0
-    steps, found := dfsSinglePath(strings.ToLower(target), make(map[string]bool), []string{}, &nodesVisited)
+    steps, found := dfsSinglePath(ctx, strings.ToLower(target), make(map[string]bool), []string{}, &nodesVisited)
     return steps, found, time.Since(start), nodesVisited
 }
 
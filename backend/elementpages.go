@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	neturl "net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/gocolly/colly"
+)
+
+// ElementDetails is what the deep crawl learns from the wiki page of one
+// element. It is stored in elements.json next to recipes.json.
+type ElementDetails struct {
+	Element      string   `json:"Element"`
+	PageURL      string   `json:"PageURL"`
+	Description  string   `json:"Description,omitempty"`
+	ImageURL     string   `json:"ImageURL,omitempty"`
+	Categories   []string `json:"Categories,omitempty"`
+	Pack         string   `json:"Pack,omitempty"`
+	UsedToCreate []string `json:"UsedToCreate,omitempty"`
+}
+
+// collectElementLinks records the page of every element of a list table,
+// the link with text in the first column
+func collectElementLinks(table *goquery.Selection, links map[string]string) {
+	table.Find("tbody tr").Each(func(_ int, row *goquery.Selection) {
+		row.Find("td:first-of-type a").Each(func(_ int, a *goquery.Selection) {
+			name := strings.ToLower(strings.TrimSpace(a.Text()))
+			if href, ok := a.Attr("href"); ok && name != "" {
+				links[name] = href
+			}
+		})
+	})
+}
+
+// parseElementPage reads the description, icon, categories and "used to
+// create" list of an element page
+func parseElementPage(r io.Reader) (ElementDetails, error) {
+	var d ElementDetails
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return d, err
+	}
+	content := doc.Find(".mw-parser-output").First()
+	if content.Length() == 0 {
+		content = doc.Selection
+	}
+
+	// First paragraph with text outside the infobox
+	content.Find("p").EachWithBreak(func(_ int, p *goquery.Selection) bool {
+		if p.ParentsFiltered("aside").Length() > 0 {
+			return true
+		}
+		d.Description = strings.Join(strings.Fields(p.Text()), " ")
+		return d.Description == ""
+	})
+
+	img := doc.Find("aside.portable-infobox figure.pi-image").First()
+	if href, ok := img.Find("a").Attr("href"); ok {
+		d.ImageURL = href
+	} else if src, ok := img.Find("img").Attr("data-src"); ok {
+		d.ImageURL = src
+	} else if src, ok := img.Find("img").Attr("src"); ok {
+		d.ImageURL = src
+	}
+
+	seen := make(map[string]bool)
+	doc.Find(`a[href*="/wiki/Category:"]`).Each(func(_ int, a *goquery.Selection) {
+		name := strings.TrimSpace(a.Text())
+		if name != "" && !seen[name] {
+			seen[name] = true
+			d.Categories = append(d.Categories, name)
+		}
+	})
+
+	// The "Used to create" section lists one combination per item or row,
+	// the result is its last linked name
+	inSection := false
+	content.Children().Each(func(_ int, s *goquery.Selection) {
+		if s.Is("h2, h3") {
+			heading := headingText(s)
+			inSection = strings.HasPrefix(heading, "used to create") || strings.HasPrefix(heading, "used in")
+			return
+		}
+		if !inSection {
+			return
+		}
+		s.Find("li, tr").Each(func(_ int, item *goquery.Selection) {
+			var last string
+			item.Find("a").Each(func(_ int, a *goquery.Selection) {
+				if text := strings.TrimSpace(a.Text()); text != "" {
+					last = strings.ToLower(text)
+				}
+			})
+			if last != "" && !containsString(d.UsedToCreate, last) {
+				d.UsedToCreate = append(d.UsedToCreate, last)
+			}
+		})
+	})
+	sort.Strings(d.UsedToCreate)
+	return d, nil
+}
+
+// elementPack picks the pack of an element from its wiki categories, or
+// from the configured pack elements
+func elementPack(name string, categories []string) string {
+	for _, p := range appConfig.Packs {
+		for _, c := range categories {
+			c = strings.ToLower(c)
+			if c == p.Name || (p.Description != "" && strings.Contains(c, strings.ToLower(p.Description))) {
+				return p.Name
+			}
+		}
+	}
+	if pack, ok := packIndex(appConfig.Packs)[name]; ok {
+		return pack
+	}
+	return BasePack
+}
+
+// crawlElementPages fetches the page of every element in links (hrefs
+// relative to pageURL) through politeTransport and its on-disk cache, at
+// most appConfig.CrawlParallelism at a time
+func crawlElementPages(ctx context.Context, pageURL string, links map[string]string, job *ScrapeJob) []ElementDetails {
+	base, err := neturl.Parse(pageURL)
+	if err != nil {
+		job.addError(err.Error())
+		return nil
+	}
+	c := colly.NewCollector(
+		colly.AllowedDomains(base.Host),
+		colly.Async(true),
+	)
+	c.IgnoreRobotsTxt = false
+	c.WithTransport(&contextTransport{ctx: ctx, base: newPoliteTransport(job)})
+	_ = c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Parallelism: appConfig.CrawlParallelism,
+		Delay:       time.Duration(appConfig.CrawlDelayMillis) * time.Millisecond,
+		RandomDelay: time.Duration(appConfig.CrawlDelayMillis) * time.Millisecond / 2,
+	})
+
+	var mu sync.Mutex
+	var details []ElementDetails
+	c.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+		}
+	})
+	c.OnResponse(func(r *colly.Response) {
+		job.pageFetched()
+		d, err := parseElementPage(bytes.NewReader(r.Body))
+		if err != nil {
+			job.addError(fmt.Sprintf("%s: %v", r.Request.URL, err))
+			return
+		}
+		d.Element = r.Ctx.Get("element")
+		d.PageURL = r.Request.URL.String()
+		d.Pack = elementPack(d.Element, d.Categories)
+		mu.Lock()
+		details = append(details, d)
+		mu.Unlock()
+	})
+	c.OnError(func(r *colly.Response, e error) {
+		job.addError(fmt.Sprintf("%s: %v", r.Request.URL, e))
+	})
+
+	names := make([]string, 0, len(links))
+	for name := range links {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if ctx.Err() != nil {
+			break
+		}
+		u, err := base.Parse(links[name])
+		if err != nil {
+			job.addError(fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		cctx := colly.NewContext()
+		cctx.Put("element", name)
+		if err := c.Request("GET", u.String(), nil, cctx, nil); err != nil {
+			job.addError(fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	c.Wait()
+
+	sort.Slice(details, func(i, j int) bool { return details[i].Element < details[j].Element })
+	fmt.Printf("[DEBUG] Crawled %d of %d element pages\n", len(details), len(links))
+	return details
+}
+
+// checkUsedToCreate compares the wiki "used to create" lists with the
+// scraped recipes and returns one line per element that disagrees
+func checkUsedToCreate(details []ElementDetails, recipes []RecipeType) []string {
+	made := make(map[string]map[string]bool)
+	for _, r := range recipes {
+		for _, ingr := range []string{r.Ingredient1, r.Ingredient2} {
+			if made[ingr] == nil {
+				made[ingr] = make(map[string]bool)
+			}
+			made[ingr][r.Element] = true
+		}
+	}
+	var out []string
+	for _, d := range details {
+		var missing []string
+		for _, result := range d.UsedToCreate {
+			if !made[d.Element][result] {
+				missing = append(missing, result)
+			}
+		}
+		if len(missing) > 0 {
+			out = append(out, fmt.Sprintf("%s: wiki says it makes %s, no scraped recipe does", d.Element, strings.Join(missing, ", ")))
+		}
+	}
+	return out
+}
+
+// saveElementDetails atomically writes elements.json
+func saveElementDetails(path string, details []ElementDetails) error {
+	data, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// loadElementDetails reads elements.json. A missing file is not an error,
+// the deep crawl is optional.
+func loadElementDetails(file string) (map[string]ElementDetails, error) {
+	out := make(map[string]ElementDetails)
+	if file == "" {
+		return out, nil
+	}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return out, nil
+	}
+	if err != nil {
+		return out, err
+	}
+	var details []ElementDetails
+	if err := json.Unmarshal(data, &details); err != nil {
+		return out, err
+	}
+	for _, d := range details {
+		out[strings.ToLower(d.Element)] = d
+	}
+	return out, nil
+}
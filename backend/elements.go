@@ -0,0 +1,113 @@
+package main
+
+import (
+	neturl "net/url"
+	"sort"
+	"strings"
+)
+
+// ElementInfo is everything the dataset knows about one element
+type ElementInfo struct {
+	Name    string     `json:"name"`
+	Tier    int        `json:"tier"`
+	Pack    string     `json:"pack"`
+	Image   string     `json:"image,omitempty"`
+	Recipes [][]string `json:"recipes"`
+	UsedIn  []string   `json:"usedIn"`
+
+	// Filled from elements.json when the deep crawl has run
+	Description string   `json:"description,omitempty"`
+	Categories  []string `json:"categories,omitempty"`
+	WikiURL     string   `json:"wikiUrl,omitempty"`
+}
+
+// lookupElement returns the recipes and uses of name from the loaded maps
+func lookupElement(name string) (ElementInfo, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	mutex.RLock()
+	defer mutex.RUnlock()
+	name = resolveAlias(name)
+
+	recipes, hasRecipes := recipesMap[name]
+	usedIn, isIngredient := revGraph[name]
+	if !hasRecipes && !isIngredient && !baseElements[name] {
+		return ElementInfo{}, false
+	}
+
+	info := ElementInfo{
+		Name:    name,
+		Tier:    tierMap[name],
+		Pack:    elementPack(name, nil),
+		Image:   elementImageURL(name),
+		Recipes: append([][]string{}, recipes...),
+		UsedIn:  append([]string{}, usedIn...),
+	}
+	sort.Strings(info.UsedIn)
+	if d, ok := elementDetails[name]; ok {
+		info.Description = d.Description
+		info.Categories = d.Categories
+		info.WikiURL = d.PageURL
+		if d.Pack != "" {
+			info.Pack = d.Pack
+		}
+		if info.Image == "" {
+			info.Image = d.ImageURL
+		}
+	}
+	return info, true
+}
+
+// elementNames returns every known element name, sorted
+func elementNames() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	seen := make(map[string]bool)
+	for name := range recipesMap {
+		seen[name] = true
+	}
+	for name := range revGraph {
+		seen[name] = true
+	}
+	for name := range baseElements {
+		seen[name] = true
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// listElements returns the elements whose name starts with prefix,
+// restricted to one tier when tier is not nil
+func listElements(prefix string, tier *int) []ElementInfo {
+	prefix = strings.ToLower(prefix)
+	var out []ElementInfo
+	for _, name := range elementNames() {
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		info, ok := lookupElement(name)
+		if !ok || (tier != nil && info.Tier != *tier) {
+			continue
+		}
+		out = append(out, info)
+	}
+	return out
+}
+
+// elementImageURL returns the public URL of the element icon, or "" if unknown.
+// Icons of the image store are served by /elements/:name/image.
+// The caller must hold mutex.
+func elementImageURL(name string) string {
+	if _, ok := storedImages[name]; ok {
+		return "/elements/" + neturl.PathEscape(name) + "/image"
+	}
+	file, ok := elementImages[name]
+	if !ok {
+		return ""
+	}
+	return appConfig.ImageBaseURL + file
+}
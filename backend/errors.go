@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+)
+
+// ErrorCode is the stable, machine-readable part of an error response.
+// Clients should branch on the code, never on the message.
+type ErrorCode string
+
+const (
+	ErrTargetRequired       ErrorCode = "TARGET_REQUIRED"
+	ErrMethodRequired       ErrorCode = "METHOD_REQUIRED"
+	ErrInvalidMethod        ErrorCode = "INVALID_METHOD"
+	ErrNumberRecipeRequired ErrorCode = "NUMBER_RECIPE_REQUIRED"
+	ErrInvalidNumberRecipe  ErrorCode = "INVALID_NUMBER_RECIPE"
+	ErrInvalidTier          ErrorCode = "INVALID_TIER"
+	ErrUnknownElement       ErrorCode = "UNKNOWN_ELEMENT"
+	ErrInvalidPack          ErrorCode = "INVALID_PACK"
+	ErrImageNotFound        ErrorCode = "IMAGE_NOT_FOUND"
+	ErrInvalidSource        ErrorCode = "INVALID_SOURCE"
+	ErrInvalidObjective     ErrorCode = "INVALID_OBJECTIVE"
+	ErrInvalidMaxDepth      ErrorCode = "INVALID_MAX_DEPTH"
+	ErrSearchTimeout        ErrorCode = "SEARCH_TIMEOUT"
+	ErrSearchBudget         ErrorCode = "SEARCH_BUDGET_EXHAUSTED"
+	ErrDatasetUnavailable   ErrorCode = "DATASET_UNAVAILABLE"
+	ErrDatasetRejected      ErrorCode = "DATASET_REJECTED"
+	ErrUnauthorized         ErrorCode = "UNAUTHORIZED"
+	ErrForbidden            ErrorCode = "FORBIDDEN"
+	ErrScrapeNetwork        ErrorCode = "SCRAPE_NETWORK_ERROR"
+	ErrScrapeFailed         ErrorCode = "SCRAPE_FAILED"
+	ErrScrapeLayout         ErrorCode = "SCRAPE_LAYOUT_MISMATCH"
+	ErrScrapeInProgress     ErrorCode = "SCRAPE_IN_PROGRESS"
+	ErrScrapeJobNotFound    ErrorCode = "SCRAPE_JOB_NOT_FOUND"
+	ErrInternal             ErrorCode = "INTERNAL_ERROR"
+)
+
+// errorDef maps a code to its HTTP status and localized messages
+type errorDef struct {
+	Status int
+	ID     string
+	EN     string
+}
+
+var errorCatalog = map[ErrorCode]errorDef{
+	ErrTargetRequired:       {http.StatusBadRequest, "Target tidak boleh kosong", "Target must not be empty"},
+	ErrMethodRequired:       {http.StatusBadRequest, "Method tidak boleh kosong", "Method must not be empty"},
+	ErrInvalidMethod:        {http.StatusBadRequest, "Method tidak valid", "Invalid method"},
+	ErrNumberRecipeRequired: {http.StatusBadRequest, "Number recipe tidak boleh kosong", "Number recipe must not be empty"},
+	ErrInvalidNumberRecipe:  {http.StatusBadRequest, "Nilai numberRecipe tidak valid", "Invalid numberRecipe value"},
+	ErrInvalidTier:          {http.StatusBadRequest, "Tier tidak valid", "Invalid tier"},
+	ErrInvalidPack:          {http.StatusBadRequest, "Paket konten tidak dikenal", "Unknown content pack"},
+	ErrUnknownElement:       {http.StatusNotFound, "Elemen tidak dikenal", "Unknown element"},
+	ErrInvalidSource:        {http.StatusBadRequest, "Sumber scraping tidak dikenal", "Unknown scrape source"},
+	ErrInvalidObjective:     {http.StatusBadRequest, "Tujuan optimasi tidak valid", "Invalid optimization objective"},
+	ErrInvalidMaxDepth:      {http.StatusBadRequest, "Nilai maxDepth tidak valid", "Invalid maxDepth value"},
+	ErrImageNotFound:        {http.StatusNotFound, "Elemen tidak memiliki gambar", "The element has no image"},
+	ErrSearchTimeout:        {http.StatusGatewayTimeout, "Pencarian melebihi batas waktu", "Search timed out"},
+	ErrSearchBudget:         {http.StatusUnprocessableEntity, "Pencarian melebihi batas jumlah node", "Search ran out of its node budget"},
+	ErrDatasetUnavailable:   {http.StatusServiceUnavailable, "Data resep tidak tersedia", "Recipe dataset is unavailable"},
+	ErrDatasetRejected:      {http.StatusUnprocessableEntity, "Data hasil scraping ditolak, data lama tetap dipakai", "Scraped dataset rejected, the previous one is kept"},
+	ErrUnauthorized:         {http.StatusUnauthorized, "API key tidak ada atau tidak valid", "Missing or invalid API key"},
+	ErrForbidden:            {http.StatusForbidden, "API key tidak memiliki akses", "API key lacks the required role"},
+	ErrScrapeNetwork:        {http.StatusServiceUnavailable, "Koneksi jaringan gagal, coba lagi nanti", "Network connection failed, please try again later"},
+	ErrScrapeFailed:         {http.StatusInternalServerError, "Scraping gagal", "Scraping failed"},
+	ErrScrapeLayout:         {http.StatusBadGateway, "Struktur halaman wiki tidak sesuai, data tidak disimpan", "The wiki page layout does not match, the dataset was not saved"},
+	ErrScrapeInProgress:     {http.StatusConflict, "Scraping lain sedang berjalan", "Another scrape job is already running"},
+	ErrScrapeJobNotFound:    {http.StatusNotFound, "Job scraping tidak ditemukan", "Scrape job not found"},
+	ErrInternal:             {http.StatusInternalServerError, "Terjadi kesalahan internal", "Internal server error"},
+}
+
+// APIError is an error carrying a stable code and optional details
+type APIError struct {
+	Code    ErrorCode
+	Details string
+}
+
+func (e *APIError) Error() string {
+	msg := errorCatalog[e.Code].EN
+	if e.Details != "" {
+		return fmt.Sprintf("%s: %s: %s", e.Code, msg, e.Details)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, msg)
+}
+
+// Extensions exposes the code in GraphQL error responses
+func (e *APIError) Extensions() map[string]interface{} {
+	return map[string]interface{}{"code": e.Code}
+}
+
+func newAPIError(code ErrorCode, details string) *APIError {
+	return &APIError{Code: code, Details: details}
+}
+
+// Status returns the HTTP status mapped to the error code
+func (e *APIError) Status() int {
+	if def, ok := errorCatalog[e.Code]; ok {
+		return def.Status
+	}
+	return http.StatusInternalServerError
+}
+
+// Message returns the message in lang ("id" or "en")
+func (e *APIError) Message(lang string) string {
+	def := errorCatalog[e.Code]
+	if lang == "en" {
+		return def.EN
+	}
+	return def.ID
+}
+
+var supportedLanguages = language.NewMatcher([]language.Tag{
+	language.Indonesian, // first entry is the fallback
+	language.English,
+})
+
+// requestLanguage picks "id" or "en" from the Accept-Language header
+func requestLanguage(c *gin.Context) string {
+	tags, _, err := language.ParseAcceptLanguage(c.GetHeader("Accept-Language"))
+	if err != nil || len(tags) == 0 {
+		return "id"
+	}
+	_, index, _ := supportedLanguages.Match(tags...)
+	if index == 1 {
+		return "en"
+	}
+	return "id"
+}
+
+// respondError writes the error envelope:
+// {"error": {"code": ..., "message": ..., "details": ...}}
+func respondError(c *gin.Context, err error) {
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		apiErr = newAPIError(ErrInternal, err.Error())
+	}
+	body := gin.H{
+		"code":    apiErr.Code,
+		"message": apiErr.Message(requestLanguage(c)),
+	}
+	if apiErr.Details != "" {
+		body["details"] = apiErr.Details
+	}
+	c.AbortWithStatusJSON(apiErr.Status(), gin.H{"error": body})
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// exactSearch is the state of the branch-and-bound behind method=exact. It
+// counts distinct combinations: an intermediate that feeds several
+// branches is crafted once. Open elements still need a recipe and are
+// expanded highest tier first. Ingredients are of a lower tier than the
+// element they make, so an open element is never one that already has a
+// recipe, and what remains to be paid only depends on the open set and
+// the base elements already used.
+type exactSearch struct {
+	ctx   context.Context
+	obj   Objective
+	graph *graphData
+	// order holds the tree costs of obj that rank the recipes to try
+	order    *knuthResult
+	chosen   map[string]solverRecipe
+	open     map[string]bool
+	bases    map[string]int // base elements used, by number of uses
+	paid     float64        // objective value of chosen and bases
+	best     map[string]solverRecipe
+	bestCost float64
+	// seen is the lowest paid any state with the same key had; a state
+	// that paid as much or more cannot do better
+	seen     map[string]float64
+	nodes    int
+	maxNodes int
+	deadline time.Time
+	aborted  bool
+	// bound is the lowest lower bound of the states left unexplored when
+	// the budget ran out
+	bound float64
+}
+
+// unit is what making element, or using a base element, adds to the
+// objective
+func (s *exactSearch) unit(element string) float64 {
+	switch s.obj.Name {
+	case OptimizeWeighted:
+		return s.obj.weight(element)
+	case OptimizeElements:
+		return 1
+	}
+	if baseElements[element] {
+		return 0
+	}
+	return 1
+}
+
+// lowerBound is the least any completion of the current state pays. Every
+// open element needs its own combination; counting combinations, so does
+// every element on the shallowest recipe chain of an open element.
+func (s *exactSearch) lowerBound() float64 {
+	need := 0.0
+	if s.obj.Name == OptimizeWeighted {
+		for e := range s.open {
+			need += s.unit(e)
+		}
+		return s.paid + need
+	}
+	need = float64(len(s.open))
+	for e := range s.open {
+		need = max(need, s.graph.minDepth.cost[e])
+	}
+	return s.paid + need
+}
+
+// nextOpen returns the open element of the highest tier, ties by name
+func (s *exactSearch) nextOpen() string {
+	next := ""
+	for e := range s.open {
+		if next == "" || tierMap[e] > tierMap[next] || (tierMap[e] == tierMap[next] && e < next) {
+			next = e
+		}
+	}
+	return next
+}
+
+func (s *exactSearch) key() string {
+	keys := make([]string, 0, len(s.open)+1+len(s.bases))
+	for e := range s.open {
+		keys = append(keys, e)
+	}
+	sort.Strings(keys)
+	if s.obj.Name != OptimizeSteps {
+		// Base elements are only paid once, the ones used matter
+		keys = append(keys, "#")
+		for _, b := range sortedKeys(s.bases) {
+			keys = append(keys, b)
+		}
+	}
+	return strings.Join(keys, "|")
+}
+
+func ingredientsOf(r solverRecipe) []string {
+	if r.a == r.b {
+		return []string{r.a}
+	}
+	return []string{r.a, r.b}
+}
+
+// choose makes element with r and returns the ingredients it opened, to be
+// handed back to unchoose
+func (s *exactSearch) choose(element string, r solverRecipe) []string {
+	s.chosen[element] = r
+	s.paid += s.unit(element)
+	var added []string
+	for _, ingr := range ingredientsOf(r) {
+		switch {
+		case baseElements[ingr]:
+			s.bases[ingr]++
+			if s.bases[ingr] == 1 {
+				s.paid += s.unit(ingr)
+			}
+		case !s.open[ingr]:
+			s.open[ingr] = true
+			added = append(added, ingr)
+		}
+	}
+	return added
+}
+
+func (s *exactSearch) unchoose(element string, r solverRecipe, added []string) {
+	for _, ingr := range ingredientsOf(r) {
+		if baseElements[ingr] {
+			s.bases[ingr]--
+			if s.bases[ingr] == 0 {
+				delete(s.bases, ingr)
+				s.paid -= s.unit(ingr)
+			}
+		}
+	}
+	for _, ingr := range added {
+		delete(s.open, ingr)
+	}
+	s.paid -= s.unit(element)
+	delete(s.chosen, element)
+}
+
+// recipeOrder lists the recipes of element that can be made, cheapest
+// first: the tree cost of the ingredients that are not open yet, so
+// recipes sharing work come before the rest
+func (s *exactSearch) recipeOrder(element string) []solverRecipe {
+	price := func(ingr string) float64 {
+		if s.open[ingr] || s.bases[ingr] > 0 {
+			return 0
+		}
+		return s.order.cost[ingr]
+	}
+	var recipes []solverRecipe
+	var prices []float64
+	for _, r := range validRecipes(element) {
+		_, okA := s.order.cost[r.a]
+		_, okB := s.order.cost[r.b]
+		if !okA || !okB {
+			continue
+		}
+		p := 0.0
+		for _, ingr := range ingredientsOf(r) {
+			p += price(ingr)
+		}
+		recipes = append(recipes, r)
+		prices = append(prices, p)
+	}
+	idx := make([]int, len(recipes))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool { return prices[idx[i]] < prices[idx[j]] })
+	out := make([]solverRecipe, len(recipes))
+	for i, k := range idx {
+		out[i] = recipes[k]
+	}
+	return out
+}
+
+func (s *exactSearch) search() {
+	lb := s.lowerBound()
+	if lb >= s.bestCost {
+		return
+	}
+	if len(s.open) == 0 {
+		s.bestCost = s.paid
+		s.best = make(map[string]solverRecipe, len(s.chosen))
+		for e, r := range s.chosen {
+			s.best[e] = r
+		}
+		return
+	}
+	key := s.key()
+	if paid, ok := s.seen[key]; ok && paid <= s.paid {
+		return
+	}
+	s.seen[key] = s.paid
+
+	s.nodes++
+	if s.nodes > s.maxNodes || time.Now().After(s.deadline) || s.ctx.Err() != nil {
+		s.aborted = true
+	}
+	if s.aborted {
+		s.bound = min(s.bound, lb)
+		return
+	}
+
+	element := s.nextOpen()
+	delete(s.open, element)
+	for _, r := range s.recipeOrder(element) {
+		added := s.choose(element, r)
+		if s.aborted {
+			// Not explored, its own bound is what we know about it
+			s.bound = min(s.bound, s.lowerBound())
+		} else {
+			s.search()
+		}
+		s.unchoose(element, r, added)
+	}
+	s.open[element] = true
+}
+
+// value is what the recipe of target with the recipes of chosen pays,
+// every element counted once
+func (s *exactSearch) value(target string, chosen map[string]solverRecipe) float64 {
+	total := 0.0
+	seen := make(map[string]bool)
+	var walk func(element string)
+	walk = func(element string) {
+		if seen[element] {
+			return
+		}
+		seen[element] = true
+		total += s.unit(element)
+		if r, ok := chosen[element]; ok && !baseElements[element] {
+			walk(r.a)
+			walk(r.b)
+		}
+	}
+	walk(target)
+	return total
+}
+
+// exactPath returns the recipe of target that minimizes obj with every
+// element made once. The search starts from the best recipe tree of
+// knuthMinCosts and stops after the configured node or time budget, or when
+// ctx is done; Gap is then how far the returned recipe may be above the
+// optimum, 0 when it is proven optimal.
+func exactPath(ctx context.Context, target string, obj Objective) (PathResult, bool, error) {
+	start := time.Now()
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	graph := cachedGraph()
+	if _, ok := graph.minSteps.cost[target]; !ok {
+		return PathResult{Runtime: time.Since(start)}, false, nil
+	}
+	if obj.Name == OptimizeDepth {
+		// Sharing an intermediate does not make a recipe shallower, the
+		// shallowest tree is optimal
+		cost, gap := graph.minDepth.cost[target], 0.0
+		steps := distinctTreeSteps(target, graph.minDepth.chosen)
+		return PathResult{Steps: steps, Runtime: time.Since(start), Cost: &cost, Gap: &gap, Objective: obj.Name}, true, nil
+	}
+
+	s := &exactSearch{
+		ctx:      ctx,
+		obj:      obj,
+		graph:    graph,
+		order:    graph.minSteps,
+		chosen:   make(map[string]solverRecipe),
+		open:     make(map[string]bool),
+		bases:    make(map[string]int),
+		seen:     make(map[string]float64),
+		maxNodes: appConfig.ExactMaxNodes,
+		deadline: start.Add(time.Duration(appConfig.ExactTimeoutMillis) * time.Millisecond),
+	}
+	if obj.Name == OptimizeWeighted {
+		s.order = knuthMinCosts(ctx, "", obj)
+		// Stopped by ctx before target was settled, there is no incumbent
+		if _, ok := s.order.cost[target]; !ok {
+			if ctx.Err() != nil {
+				return PathResult{Runtime: time.Since(start)}, false, searchStopped(ctx)
+			}
+			return PathResult{Runtime: time.Since(start)}, false, nil
+		}
+	}
+	// The best tree is a solution, with its elements made once the first
+	// incumbent
+	s.best, s.bestCost = s.order.chosen, s.value(target, s.order.chosen)
+	s.bound = s.bestCost
+	if baseElements[target] {
+		s.bases[target] = 1
+		s.paid = s.unit(target)
+	} else {
+		s.open[target] = true
+	}
+	s.search()
+
+	steps := distinctTreeSteps(target, s.best)
+	cost := s.bestCost
+	gap := 0.0
+	if s.aborted {
+		gap = cost - min(s.bound, cost)
+	}
+	return PathResult{Steps: steps, Runtime: time.Since(start), NodesVisited: s.nodes, Cost: &cost, Gap: &gap, Objective: obj.Name}, true, nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// sharedGraph makes target cheapest as a tree from c and d, but with every
+// element made once from a and b, which share x
+var sharedGraph = []Recipe{
+	{Element: "x", Ingredient1: "fire", Ingredient2: "water", Type: 1},
+	{Element: "p", Ingredient1: "fire", Ingredient2: "earth", Type: 1},
+	{Element: "q", Ingredient1: "water", Ingredient2: "air", Type: 1},
+	{Element: "a", Ingredient1: "x", Ingredient2: "x", Type: 2},
+	{Element: "b", Ingredient1: "x", Ingredient2: "earth", Type: 2},
+	{Element: "c", Ingredient1: "p", Ingredient2: "fire", Type: 2},
+	{Element: "d", Ingredient1: "q", Ingredient2: "air", Type: 2},
+	{Element: "target", Ingredient1: "a", Ingredient2: "b", Type: 3},
+	{Element: "target", Ingredient1: "c", Ingredient2: "d", Type: 3},
+}
+
+// useExactBudget sets the node budget of the exact search for the
+// duration of the test
+func useExactBudget(t *testing.T, nodes int) {
+	t.Helper()
+	old := appConfig
+	c := *appConfig
+	c.ExactMaxNodes = nodes
+	appConfig = &c
+	t.Cleanup(func() { appConfig = old })
+}
+
+func TestExactMatchesBruteForce(t *testing.T) {
+	objectives := append([]Objective{{Name: OptimizeElements}}, testObjectives...)
+	for name, graph := range map[string][]Recipe{"small": smallGraph, "shared": sharedGraph} {
+		t.Run(name, func(t *testing.T) {
+			useGraph(t, graph)
+			trees := bruteTrees()
+			ctx := context.Background()
+			for _, obj := range objectives {
+				for _, e := range craftedElements() {
+					exact, found, err := exactPath(ctx, e, obj)
+					if err != nil || !found {
+						t.Fatalf("%s %s: exact found=%v err=%v", obj.Name, e, found, err)
+					}
+					if err := validatePath(e, exact.Steps); err != nil {
+						t.Errorf("%s %s: exact %v", obj.Name, e, err)
+					}
+					if *exact.Gap != 0 {
+						t.Errorf("%s %s: finished search has gap %v", obj.Name, e, *exact.Gap)
+					}
+					if obj.Name == OptimizeDepth {
+						continue
+					}
+					want := bruteMin(trees[e], func(b *bruteTree) float64 { return b.distinctCost(obj) })
+					if *exact.Cost != want {
+						t.Errorf("%s %s: exact cost %v, brute force %v", obj.Name, e, *exact.Cost, want)
+					}
+					if obj.Name == OptimizeElements {
+						continue
+					}
+					optimal, _, _ := optimalPath(ctx, e, obj)
+					if *exact.Cost > *optimal.Cost {
+						t.Errorf("%s %s: exact cost %v above optimal %v", obj.Name, e, *exact.Cost, *optimal.Cost)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestExactSharesIntermediates(t *testing.T) {
+	useGraph(t, sharedGraph)
+	obj := Objective{Name: OptimizeSteps}
+	exact, _, _ := exactPath(context.Background(), "target", obj)
+	optimal, _, _ := optimalPath(context.Background(), "target", obj)
+	if *exact.Cost != 4 || *optimal.Cost != 5 {
+		t.Errorf("want exact 4 below optimal 5, got exact %v optimal %v", *exact.Cost, *optimal.Cost)
+	}
+}
+
+func TestExactGapWhenBudgetRunsOut(t *testing.T) {
+	useGraph(t, sharedGraph)
+	useExactBudget(t, 1)
+	obj := Objective{Name: OptimizeSteps}
+	want := bruteMin(bruteTrees()["target"], func(b *bruteTree) float64 { return b.distinctCost(obj) })
+
+	exact, found, err := exactPath(context.Background(), "target", obj)
+	if err != nil || !found {
+		t.Fatalf("exact found=%v err=%v", found, err)
+	}
+	if err := validatePath("target", exact.Steps); err != nil {
+		t.Error(err)
+	}
+	if *exact.Gap <= 0 {
+		t.Errorf("want a gap after %d nodes, got %v", exact.NodesVisited, *exact.Gap)
+	}
+	if *exact.Cost < want || *exact.Cost-*exact.Gap > want {
+		t.Errorf("cost %v with gap %v does not bound the optimum %v", *exact.Cost, *exact.Gap, want)
+	}
+}
+
+func TestExactStoppedBeforeIncumbent(t *testing.T) {
+	useGraph(t, smallGraph)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	obj := Objective{Name: OptimizeWeighted, Weights: map[string]float64{"stone": 2}}
+	exact, found, err := exactPath(ctx, "window", obj)
+	var apiErr *APIError
+	if found || !errors.As(err, &apiErr) || apiErr.Code != ErrSearchTimeout {
+		t.Fatalf("want %s and nothing found, got found=%v steps=%v err=%v", ErrSearchTimeout, found, exact.Steps, err)
+	}
+}
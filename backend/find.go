@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// findMethods are the accepted values of the method parameter of /find
+var findMethods = []string{"bfs", "dfs", "optimal", "exact", "astar", "iddfs", "kbest"}
+
+// FindRequest is one search as accepted by /find
+type FindRequest struct {
+	Target        string
+	Method        string
+	Count         int
+	Bidirectional bool
+	// Packs are the content packs whose recipes may be used, nil means
+	// the configured default packs
+	Packs []string
+	// Objective is what the optimal solvers minimize, the zero value is
+	// OptimizeSteps
+	Objective Objective
+	// MaxDepth is the deepest limit iddfs tries, 0 means the configured
+	// maxDepth
+	MaxDepth int
+}
+
+// PathResult is one recipe found by a search
+type PathResult struct {
+	Steps        []string
+	Runtime      time.Duration
+	NodesVisited int
+	// Cost is the objective value of the optimal solvers, nil otherwise
+	Cost *float64
+	// Objective is the name of the objective Cost is measured in
+	Objective string
+	// NodesByDepth are the nodes visited by every iteration of iddfs
+	NodesByDepth []DepthNodes
+	// Gap is how far Cost may be above the optimum when a solver ran out of
+	// budget, 0 when Cost is proven optimal. nil for solvers without budget.
+	Gap *float64
+}
+
+// FindResult is the outcome of findRecipes, independent of the transport
+type FindResult struct {
+	Found        bool
+	Paths        []PathResult
+	Runtime      time.Duration
+	NodesVisited int
+	// NodesByDepth is set by iddfs, also when nothing was found
+	NodesByDepth []DepthNodes
+}
+
+// parseFindRequest validates the raw /find query values
+func parseFindRequest(target, method, numberRecipe, bidirectional string) (FindRequest, error) {
+	if target == "" {
+		return FindRequest{}, newAPIError(ErrTargetRequired, "")
+	}
+	if method == "" {
+		return FindRequest{}, newAPIError(ErrMethodRequired, "")
+	}
+	if !containsString(findMethods, method) {
+		return FindRequest{}, newAPIError(ErrInvalidMethod, method)
+	}
+	if numberRecipe == "" {
+		return FindRequest{}, newAPIError(ErrNumberRecipeRequired, "")
+	}
+	count, err := strconv.Atoi(numberRecipe)
+	if err != nil || count < 1 {
+		return FindRequest{}, newAPIError(ErrInvalidNumberRecipe, numberRecipe)
+	}
+	return FindRequest{
+		Target:        strings.ToLower(strings.TrimSpace(target)),
+		Method:        method,
+		Count:         count,
+		Bidirectional: bidirectional == "true",
+	}, nil
+}
+
+// findRecipes runs the solver selected by req against the loaded recipe maps.
+// onPath, if not nil, is called with every path as soon as it is available;
+// only the DFS worker pool produces paths before the search is over. The
+// solvers stop when ctx is done and release the recipe maps before
+// findRecipes returns; with nothing found that is a search error.
+func findRecipes(ctx context.Context, req FindRequest, onPath func(PathResult)) (*FindResult, error) {
+	if req.Count > appConfig.MaxNumberRecipe {
+		return nil, newAPIError(ErrInvalidNumberRecipe, fmt.Sprintf("%d is above the limit of %d", req.Count, appConfig.MaxNumberRecipe))
+	}
+	acquirePacks(req.Packs)
+	defer releasePacks()
+
+	mutex.RLock()
+	target := resolveAlias(strings.ToLower(req.Target))
+	obj, err := req.Objective.resolve()
+	mutex.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := recipesMap[target]; !ok && !baseElements[target] {
+		return nil, newAPIError(ErrUnknownElement, req.Target)
+	}
+
+	var result *FindResult
+	switch {
+	// The recipe trees of target in cost order, the first the one optimal
+	// returns. Stopped by ctx it keeps the trees it already has.
+	case req.Method == "kbest":
+		if obj.Name == OptimizeElements {
+			return nil, newAPIError(ErrInvalidObjective, "kbest ranks recipe trees, optimize=elements is not a tree cost")
+		}
+		paths, elapsed, nodes := kBestPaths(ctx, target, req.Count, obj)
+		result = &FindResult{Found: len(paths) > 0, Paths: paths, Runtime: elapsed, NodesVisited: nodes}
+	// Bidirectional does not apply to the optimal solvers, and they return
+	// one recipe
+	case containsString(optimalMethods, req.Method):
+		solve := optimalPath
+		switch req.Method {
+		case "exact":
+			solve = exactPath
+		case "astar":
+			solve = astarPath
+		}
+		path, ok, err := solve(ctx, target, obj)
+		if err != nil {
+			return nil, err
+		}
+		result = &FindResult{Found: ok, Runtime: path.Runtime, NodesVisited: path.NodesVisited}
+		if ok {
+			result.Paths = []PathResult{path}
+		}
+	case req.Method == "iddfs":
+		// Also a single recipe: the shallowest one
+		maxDepth := req.MaxDepth
+		if maxDepth == 0 {
+			maxDepth = appConfig.MaxDepth
+		}
+		steps, ok, elapsed, nodes, byDepth := iddfsPath(ctx, target, maxDepth)
+		result = &FindResult{Found: ok, Runtime: elapsed, NodesVisited: nodes, NodesByDepth: byDepth}
+		if ok {
+			result.Paths = []PathResult{{Steps: steps, Runtime: elapsed, NodesVisited: nodes, NodesByDepth: byDepth}}
+		}
+	case req.Count == 1:
+		var (
+			steps   []string
+			ok      bool
+			elapsed time.Duration
+			nodes   int
+		)
+		switch {
+		case req.Method == "bfs" && req.Bidirectional:
+			steps, ok, elapsed, nodes = bfsBidirectionalPath(ctx, target)
+		case req.Method == "bfs":
+			steps, ok, elapsed, nodes = bfsSinglePath(ctx, target)
+		case req.Method == "dfs" && req.Bidirectional:
+			steps, ok, elapsed, nodes = dfsBidirectionalPath(ctx, target)
+		case req.Method == "dfs":
+			steps, ok, elapsed, nodes = DFSWrapper(ctx, target)
+		default:
+			return nil, newAPIError(ErrInvalidMethod, req.Method)
+		}
+		result = &FindResult{Found: ok, Runtime: elapsed, NodesVisited: nodes}
+		if ok {
+			result.Paths = []PathResult{{Steps: steps, Runtime: elapsed, NodesVisited: nodes}}
+		}
+	default:
+		switch req.Method {
+		case "bfs":
+			paths, found, elapsed, nodes := bfsMultiplePaths(ctx, target, req.Count)
+			result = &FindResult{Found: found, Runtime: elapsed, NodesVisited: nodes}
+			if found {
+				for _, p := range paths {
+					result.Paths = append(result.Paths, PathResult{Steps: p, Runtime: elapsed, NodesVisited: nodes})
+				}
+			}
+		case "dfs":
+			start := time.Now()
+			paths := dfsWorkerPool(ctx, target, req.Count, onPath)
+			result = &FindResult{Found: len(paths) > 0, Paths: paths, Runtime: time.Since(start)}
+			for _, p := range paths {
+				result.NodesVisited += p.NodesVisited
+			}
+			if !result.Found && ctx.Err() != nil {
+				return nil, searchStopped(ctx)
+			}
+			return result, nil
+		default:
+			return nil, newAPIError(ErrInvalidMethod, req.Method)
+		}
+	}
+
+	if !result.Found && ctx.Err() != nil {
+		return nil, searchStopped(ctx)
+	}
+	if onPath != nil {
+		for _, p := range result.Paths {
+			onPath(p)
+		}
+	}
+	return result, nil
+}
+
+// findWithTimeout runs findRecipes, cancelling it after timeout (0 means no
+// limit). It returns once the solver stopped, so a timed out search does not
+// keep running or holding the recipe maps.
+func findWithTimeout(ctx context.Context, req FindRequest, timeout time.Duration) (*FindResult, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	result, err := findRecipes(ctx, req, nil)
+	if apiErr, ok := err.(*APIError); ok && apiErr.Code == ErrSearchTimeout && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return nil, newAPIError(ErrSearchTimeout, fmt.Sprintf("no result after %s", timeout))
+	}
+	return result, err
+}
+
+// searchStopped is the error of a search whose ctx ended before it found
+// anything
+func searchStopped(ctx context.Context) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return newAPIError(ErrSearchTimeout, "deadline exceeded")
+	}
+	return newAPIError(ErrSearchTimeout, "search cancelled")
+}
+
+// dfsWorkerPool spreads the recipes of target over the DFS workers and
+// collects up to count distinct paths, passing each one to onPath if set.
+// The workers are stopped once it returns.
+func dfsWorkerPool(ctx context.Context, target string, count int, onPath func(PathResult)) []PathResult {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	jobs := make(chan Job)
+	results := make(chan JobResultDFS)
+	var wg sync.WaitGroup
+
+	for i := 0; i < numWorkers; i++ {
+		wg.Add(1)
+		go worker(ctx, i, jobs, results, &wg)
+	}
+
+	go func() {
+		for i := range recipesMap[target] {
+			jobs <- Job{JobID: i + 1, JobType: "dfs", Target: target, Limit: count}
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	seenPaths := make(map[string]bool)
+	var paths []PathResult
+	for res := range results {
+		key := strings.Join(res.Steps, "|")
+		if seenPaths[key] {
+			continue
+		}
+		seenPaths[key] = true
+		path := PathResult{Steps: res.Steps, Runtime: res.Duration, NodesVisited: res.NodesVisited}
+		paths = append(paths, path)
+		if onPath != nil {
+			onPath(path)
+		}
+		if len(paths) >= count {
+			break
+		}
+	}
+	// Stop the remaining workers and wait for them, they read the recipe maps
+	cancel()
+	for range results {
+	}
+	return paths
+}
+
+// parseStep splits "a + b = c" into its ingredients and result
+func parseStep(step string) (string, string, string, bool) {
+	parts := strings.Split(step, " = ")
+	if len(parts) != 2 {
+		return "", "", "", false
+	}
+	ingredients := strings.Split(parts[0], " + ")
+	if len(ingredients) != 2 {
+		return "", "", "", false
+	}
+	return ingredients[0], ingredients[1], parts[1], true
+}
+
+// legacyJSON renders the result in the shape /find has always returned:
+// a Result object for single searches, a list of "Path N" maps otherwise
+func (r *FindResult) legacyJSON(req FindRequest) interface{} {
+	if req.Count == 1 {
+		result := Result{Found: r.Found, Runtime: r.Runtime.String(), NodesVisited: r.NodesVisited, NodesByDepth: r.NodesByDepth}
+		if len(r.Paths) > 0 {
+			result.Steps = r.Paths[0].Steps
+			result.Cost = r.Paths[0].Cost
+			result.Gap = r.Paths[0].Gap
+			result.Objective = r.Paths[0].Objective
+		}
+		return result
+	}
+
+	resultsJSON := make([]map[string][]string, 0, len(r.Paths))
+	for i, p := range r.Paths {
+		pathJSON := make(map[string][]string)
+		pathJSON[fmt.Sprintf("Path %d", i+1)] = p.Steps
+		pathJSON["Runtime"] = []string{p.Runtime.String()}
+		pathJSON["NodesVisited"] = []string{strconv.Itoa(p.NodesVisited)}
+		if p.Cost != nil {
+			pathJSON["Cost"] = []string{strconv.FormatFloat(*p.Cost, 'f', -1, 64)}
+			pathJSON["Objective"] = []string{p.Objective}
+		}
+		for _, d := range p.NodesByDepth {
+			pathJSON["NodesByDepth"] = append(pathJSON["NodesByDepth"], fmt.Sprintf("%d:%d", d.Depth, d.Nodes))
+		}
+		if p.Gap != nil {
+			pathJSON["Gap"] = []string{strconv.FormatFloat(*p.Gap, 'f', -1, 64)}
+		}
+		resultsJSON = append(resultsJSON, pathJSON)
+	}
+	return resultsJSON
+}
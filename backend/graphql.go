@@ -0,0 +1,428 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/graphql-go/graphql"
+)
+
+// recipeRef is the GraphQL source of a Recipe: ingredient1 + ingredient2 = result
+type recipeRef struct {
+	Ingredient1 string
+	Ingredient2 string
+	Result      string
+}
+
+// recipeTree is one node of a structured recipe: how element was made from
+// its two ingredients. Base elements and elements without a known step are leaves.
+type recipeTree struct {
+	Element  string
+	Recipe   *recipeRef
+	Children []*recipeTree
+}
+
+// buildRecipeTree turns the flat "a + b = c" steps of a path into a tree rooted at target
+func buildRecipeTree(target string, steps []string) *recipeTree {
+	made := make(map[string]recipeRef)
+	for _, step := range steps {
+		a, b, result, ok := parseStep(step)
+		if ok {
+			made[result] = recipeRef{Ingredient1: a, Ingredient2: b, Result: result}
+		}
+	}
+
+	var build func(element string, visiting map[string]bool) *recipeTree
+	build = func(element string, visiting map[string]bool) *recipeTree {
+		node := &recipeTree{Element: element}
+		r, ok := made[element]
+		if !ok || baseElements[element] || visiting[element] {
+			return node
+		}
+		visiting[element] = true
+		defer delete(visiting, element)
+		node.Recipe = &r
+		node.Children = []*recipeTree{
+			build(r.Ingredient1, visiting),
+			build(r.Ingredient2, visiting),
+		}
+		return node
+	}
+	return build(target, make(map[string]bool))
+}
+
+// graphqlPath is the GraphQL source of one found path
+type graphqlPath struct {
+	Index  int
+	Target string
+	PathResult
+}
+
+var graphqlSchema = newGraphQLSchema()
+
+func newGraphQLSchema() graphql.Schema {
+	var elementType, recipeType, treeType *graphql.Object
+
+	elementType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Element",
+		Description: "An element of the game, identified by its lowercase name",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"name": &graphql.Field{Type: graphql.NewNonNull(graphql.String)},
+				"tier": &graphql.Field{
+					Type: graphql.Int,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						info, _ := lookupElement(p.Source.(ElementInfo).Name)
+						return info.Tier, nil
+					},
+				},
+				"image": &graphql.Field{
+					Type:        graphql.String,
+					Description: "URL of the element icon, null when unknown",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						info, _ := lookupElement(p.Source.(ElementInfo).Name)
+						if info.Image == "" {
+							return nil, nil
+						}
+						return info.Image, nil
+					},
+				},
+				"pack": &graphql.Field{
+					Type: graphql.String,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						info, _ := lookupElement(p.Source.(ElementInfo).Name)
+						return info.Pack, nil
+					},
+				},
+				"description": &graphql.Field{
+					Type:        graphql.String,
+					Description: "Wiki description, null until the deep crawl has run",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						info, _ := lookupElement(p.Source.(ElementInfo).Name)
+						if info.Description == "" {
+							return nil, nil
+						}
+						return info.Description, nil
+					},
+				},
+				"recipes": &graphql.Field{
+					Type:        graphql.NewList(recipeType),
+					Description: "Every combination producing this element",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						info, _ := lookupElement(p.Source.(ElementInfo).Name)
+						out := make([]recipeRef, 0, len(info.Recipes))
+						for _, r := range info.Recipes {
+							out = append(out, recipeRef{Ingredient1: r[0], Ingredient2: r[1], Result: info.Name})
+						}
+						return out, nil
+					},
+				},
+				"usedIn": &graphql.Field{
+					Type:        graphql.NewList(elementType),
+					Description: "Elements that have this element as an ingredient",
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						info, _ := lookupElement(p.Source.(ElementInfo).Name)
+						return elementRefs(info.UsedIn), nil
+					},
+				},
+			}
+		}),
+	})
+
+	recipeType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "Recipe",
+		Description: "One combination: the two ingredients and the result",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"ingredients": &graphql.Field{
+					Type: graphql.NewList(elementType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						r := p.Source.(recipeRef)
+						return elementRefs([]string{r.Ingredient1, r.Ingredient2}), nil
+					},
+				},
+				"result": &graphql.Field{
+					Type: elementType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return ElementInfo{Name: p.Source.(recipeRef).Result}, nil
+					},
+				},
+			}
+		}),
+	})
+
+	treeType = graphql.NewObject(graphql.ObjectConfig{
+		Name:        "RecipeTree",
+		Description: "A structured recipe, ingredients are the trees of the two inputs",
+		Fields: graphql.FieldsThunk(func() graphql.Fields {
+			return graphql.Fields{
+				"element": &graphql.Field{
+					Type: graphql.NewNonNull(elementType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return ElementInfo{Name: p.Source.(*recipeTree).Element}, nil
+					},
+				},
+				"recipe": &graphql.Field{
+					Type: recipeType,
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						if r := p.Source.(*recipeTree).Recipe; r != nil {
+							return *r, nil
+						}
+						return nil, nil
+					},
+				},
+				"ingredients": &graphql.Field{
+					Type: graphql.NewList(treeType),
+					Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+						return p.Source.(*recipeTree).Children, nil
+					},
+				},
+			}
+		}),
+	})
+
+	depthType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "DepthNodes",
+		Fields: graphql.Fields{
+			"depth": &graphql.Field{Type: graphql.Int},
+			"nodes": &graphql.Field{Type: graphql.Int},
+		},
+	})
+
+	pathType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "RecipePath",
+		Fields: graphql.Fields{
+			"index": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(graphqlPath).Index, nil
+				},
+			},
+			"cost": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "Objective value, only set by the optimal solvers",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if cost := p.Source.(graphqlPath).Cost; cost != nil {
+						return *cost, nil
+					}
+					return nil, nil
+				},
+			},
+			"objective": &graphql.Field{
+				Type:        graphql.String,
+				Description: "What cost measures: steps, depth, elements or weighted",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if o := p.Source.(graphqlPath).Objective; o != "" {
+						return o, nil
+					}
+					return nil, nil
+				},
+			},
+			"gap": &graphql.Field{
+				Type:        graphql.Float,
+				Description: "How far cost may be above the optimum, 0 when proven optimal; only set by the exact solver",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					if gap := p.Source.(graphqlPath).Gap; gap != nil {
+						return *gap, nil
+					}
+					return nil, nil
+				},
+			},
+			"steps": &graphql.Field{
+				Type: graphql.NewList(recipeType),
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var out []recipeRef
+					for _, step := range p.Source.(graphqlPath).Steps {
+						if a, b, result, ok := parseStep(step); ok {
+							out = append(out, recipeRef{Ingredient1: a, Ingredient2: b, Result: result})
+						}
+					}
+					return out, nil
+				},
+			},
+			"tree": &graphql.Field{
+				Type: treeType,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					path := p.Source.(graphqlPath)
+					return buildRecipeTree(path.Target, path.Steps), nil
+				},
+			},
+			"runtime": &graphql.Field{
+				Type: graphql.String,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(graphqlPath).Runtime.String(), nil
+				},
+			},
+			"nodesVisited": &graphql.Field{
+				Type: graphql.Int,
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(graphqlPath).NodesVisited, nil
+				},
+			},
+			"nodesByDepth": &graphql.Field{
+				Type:        graphql.NewList(depthType),
+				Description: "Nodes visited by every iteration, only set by iddfs",
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					return p.Source.(graphqlPath).NodesByDepth, nil
+				},
+			},
+		},
+	})
+
+	findType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "FindResult",
+		Fields: graphql.Fields{
+			"found":        &graphql.Field{Type: graphql.Boolean},
+			"runtime":      &graphql.Field{Type: graphql.String},
+			"nodesVisited": &graphql.Field{Type: graphql.Int},
+			"nodesByDepth": &graphql.Field{Type: graphql.NewList(depthType)},
+			"paths":        &graphql.Field{Type: graphql.NewList(pathType)},
+		},
+	})
+
+	queryType := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"element": &graphql.Field{
+				Type: elementType,
+				Args: graphql.FieldConfigArgument{
+					"name": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					info, ok := lookupElement(p.Args["name"].(string))
+					if !ok {
+						return nil, nil
+					}
+					return info, nil
+				},
+			},
+			"elements": &graphql.Field{
+				Type: graphql.NewList(elementType),
+				Args: graphql.FieldConfigArgument{
+					"prefix": &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""},
+					"tier":   &graphql.ArgumentConfig{Type: graphql.Int},
+				},
+				Resolve: func(p graphql.ResolveParams) (interface{}, error) {
+					var tier *int
+					if t, ok := p.Args["tier"].(int); ok {
+						tier = &t
+					}
+					return listElements(p.Args["prefix"].(string), tier), nil
+				},
+			},
+			"find": &graphql.Field{
+				Type:        findType,
+				Description: "Runs the same search as /find and returns structured recipe trees",
+				Args: graphql.FieldConfigArgument{
+					"target":        &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"method":        &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: "bfs"},
+					"count":         &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 1},
+					"bidirectional": &graphql.ArgumentConfig{Type: graphql.Boolean, DefaultValue: false},
+					"optimize":      &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""},
+					"weights":       &graphql.ArgumentConfig{Type: graphql.String, DefaultValue: ""},
+					"maxDepth":      &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 0},
+					"packs":         &graphql.ArgumentConfig{Type: graphql.String, Description: "Comma-separated packs to search, the default packs when omitted"},
+				},
+				Resolve: resolveFind,
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: queryType})
+	if err != nil {
+		panic(err)
+	}
+	return schema
+}
+
+func resolveFind(p graphql.ResolveParams) (interface{}, error) {
+	bidirectional := ""
+	if p.Args["bidirectional"].(bool) {
+		bidirectional = "true"
+	}
+	req, err := parseFindRequest(p.Args["target"].(string), p.Args["method"].(string),
+		strconv.Itoa(p.Args["count"].(int)), bidirectional)
+	if err != nil {
+		return nil, err
+	}
+	if req.Objective, err = parseObjective(req.Method, p.Args["optimize"].(string), p.Args["weights"].(string)); err != nil {
+		return nil, err
+	}
+	if d := p.Args["maxDepth"].(int); d != 0 {
+		if req.MaxDepth, err = parseMaxDepth(req.Method, strconv.Itoa(d)); err != nil {
+			return nil, err
+		}
+	}
+	if packs, ok := p.Args["packs"].(string); ok {
+		if req.Packs, err = parsePacks(packs); err != nil {
+			return nil, err
+		}
+		// GraphQLHandler holds the default packs, findRecipes could not
+		// switch to these while it does
+		releasePacks()
+		defer acquirePacks(nil)
+	}
+	result, err := findWithTimeout(p.Context, req, time.Duration(appConfig.SearchTimeoutSeconds)*time.Second)
+	if err != nil {
+		return nil, err
+	}
+
+	paths := make([]graphqlPath, 0, len(result.Paths))
+	for i, path := range result.Paths {
+		paths = append(paths, graphqlPath{Index: i + 1, Target: req.Target, PathResult: path})
+	}
+	return map[string]interface{}{
+		"found":        result.Found,
+		"runtime":      result.Runtime.String(),
+		"nodesVisited": result.NodesVisited,
+		"nodesByDepth": result.NodesByDepth,
+		"paths":        paths,
+	}, nil
+}
+
+// elementRefs wraps names as Element sources, the other fields resolve lazily
+func elementRefs(names []string) []ElementInfo {
+	out := make([]ElementInfo, 0, len(names))
+	for _, name := range names {
+		out = append(out, ElementInfo{Name: name})
+	}
+	return out
+}
+
+// graphqlRequest is the standard GraphQL-over-HTTP body
+type graphqlRequest struct {
+	Query         string                 `json:"query" form:"query"`
+	OperationName string                 `json:"operationName" form:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// GraphQLHandler serves POST (JSON body) and GET (?query=) GraphQL requests
+func GraphQLHandler(c *gin.Context) {
+	var req graphqlRequest
+	if c.Request.Method == http.MethodGet {
+		req.Query = c.Query("query")
+		req.OperationName = c.Query("operationName")
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"errors": []gin.H{{"message": err.Error()}}})
+		return
+	}
+
+	if err := loadDataset(appConfig.DataPath); err != nil {
+		respondError(c, newAPIError(ErrDatasetUnavailable, err.Error()))
+		return
+	}
+
+	// Element fields resolve against the default packs; find does too
+	acquirePacks(nil)
+	defer releasePacks()
+	result := graphql.Do(graphql.Params{
+		Schema:         graphqlSchema,
+		RequestString:  req.Query,
+		OperationName:  req.OperationName,
+		VariableValues: req.Variables,
+		Context:        c.Request.Context(),
+	})
+	c.JSON(http.StatusOK, result)
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// useDataset serves recipes from a temp dataset with the default packs
+// and no overrides or images for the duration of the test
+func useDataset(t *testing.T, recipes []RecipeType) {
+	t.Helper()
+	dir := t.TempDir()
+	old := appConfig
+	c := *appConfig
+	c.DataPath = filepath.Join(dir, "recipes.json")
+	c.OverridesPath = ""
+	c.ImageMapPath = ""
+	c.ImageStore = filepath.Join(dir, "images")
+	c.ElementsPath = ""
+	c.Packs = defaultPacks()
+	c.DefaultPacks = []string{BasePack}
+	appConfig = &c
+	if _, err := saveRecipes(c.DataPath, recipes, true); err != nil {
+		t.Fatal(err)
+	}
+
+	mutex.Lock()
+	oldRecipes, oldTiers, oldRev, oldWeights := recipesMap, tierMap, revGraph, elementWeights
+	oldLoaded, oldActive, oldAliases, oldReport := loadedRecipes, activePacks, elementAliases, overrideReport
+	oldImages, oldStored, oldDetails := elementImages, storedImages, elementDetails
+	recipesMap, activePacks = nil, nil
+	mutex.Unlock()
+	t.Cleanup(func() {
+		appConfig = old
+		mutex.Lock()
+		recipesMap, tierMap, revGraph, elementWeights = oldRecipes, oldTiers, oldRev, oldWeights
+		loadedRecipes, activePacks, elementAliases, overrideReport = oldLoaded, oldActive, oldAliases, oldReport
+		elementImages, storedImages, elementDetails = oldImages, oldStored, oldDetails
+		graphGeneration++
+		mutex.Unlock()
+		packGate.Lock()
+		packGate.key = ""
+		packGate.Unlock()
+	})
+}
+
+func TestGraphQLFindPacks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useAPIKeys(t, false)
+	useDataset(t, []RecipeType{
+		{Element: "mud", Ingredient1: "water", Ingredient2: "earth", Type: 1, Pack: BasePack},
+		{Element: "stone", Ingredient1: "mud", Ingredient2: "fire", Type: 2, Pack: BasePack},
+		{Element: "monster", Ingredient1: "mud", Ingredient2: "air", Type: 2, Pack: "myths"},
+	})
+	r := newRouter(appConfig)
+
+	tests := []struct {
+		name  string
+		query string
+		found bool
+		code  ErrorCode
+	}{
+		{"base element, default packs", `{ find(target: "stone") { found } }`, true, ""},
+		{"pack element, default packs", `{ find(target: "monster") { found } }`, false, ErrUnknownElement},
+		{"pack element, its pack", `{ find(target: "monster", packs: "base,myths") { found } }`, true, ""},
+		{"unknown pack", `{ find(target: "monster", packs: "nope") { found } }`, false, ErrInvalidPack},
+		{"element fields after a find with packs", `{ a: find(target: "monster", packs: "myths") { found } element(name: "stone") { name } b: find(target: "stone") { found } }`, true, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, _ := json.Marshal(graphqlRequest{Query: tt.query})
+			req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d: %s", w.Code, w.Body)
+			}
+
+			var resp struct {
+				Data   map[string]json.RawMessage `json:"data"`
+				Errors []struct {
+					Extensions struct {
+						Code ErrorCode `json:"code"`
+					} `json:"extensions"`
+				} `json:"errors"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatal(err)
+			}
+			if tt.code != "" {
+				if len(resp.Errors) != 1 || resp.Errors[0].Extensions.Code != tt.code {
+					t.Errorf("want %s, got %s", tt.code, w.Body)
+				}
+				return
+			}
+			if len(resp.Errors) > 0 {
+				t.Fatalf("errors: %s", w.Body)
+			}
+			for field, raw := range resp.Data {
+				var find struct {
+					Found *bool `json:"found"`
+				}
+				if err := json.Unmarshal(raw, &find); err != nil || find.Found == nil {
+					continue
+				}
+				if *find.Found != tt.found {
+					t.Errorf("%s found %v, want %v", field, *find.Found, tt.found)
+				}
+			}
+		})
+	}
+}
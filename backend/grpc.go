@@ -0,0 +1,300 @@
+package main
+
+//go:generate protoc -I proto --go_out=recipepb --go_opt=paths=source_relative --go-grpc_out=recipepb --go-grpc_opt=paths=source_relative recipe.proto
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"strings"
+	"time"
+
+	"arachemy/recipepb"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+// recipeServer implements recipepb.RecipeServiceServer on top of findRecipes
+type recipeServer struct {
+	recipepb.UnimplementedRecipeServiceServer
+}
+
+// startGRPCServer serves RecipeService on cfg.GRPCPort until the listener fails
+func startGRPCServer(cfg *Config) error {
+	lis, err := net.Listen("tcp", "0.0.0.0:"+cfg.GRPCPort)
+	if err != nil {
+		return err
+	}
+	s := grpc.NewServer(
+		grpc.UnaryInterceptor(grpcAuthUnary),
+		grpc.StreamInterceptor(grpcAuthStream),
+	)
+	recipepb.RegisterRecipeServiceServer(s, &recipeServer{})
+	reflection.Register(s)
+	log.Printf("gRPC listening on 0.0.0.0:%s\n", cfg.GRPCPort)
+	return s.Serve(lis)
+}
+
+func (s *recipeServer) Find(ctx context.Context, in *recipepb.FindRequest) (*recipepb.FindResponse, error) {
+	req, err := findRequestFromProto(in)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	if err := loadDataset(appConfig.DataPath); err != nil {
+		return nil, grpcError(newAPIError(ErrDatasetUnavailable, err.Error()))
+	}
+
+	result, err := findWithTimeout(ctx, req, time.Duration(appConfig.SearchTimeoutSeconds)*time.Second)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	out := &recipepb.FindResponse{
+		Found:        result.Found,
+		Runtime:      durationpb.New(result.Runtime),
+		NodesVisited: int32(result.NodesVisited),
+		NodesByDepth: depthNodesToProto(result.NodesByDepth),
+	}
+	for i, p := range result.Paths {
+		out.Paths = append(out.Paths, pathToProto(i+1, p))
+	}
+	return out, nil
+}
+
+func (s *recipeServer) StreamFind(in *recipepb.FindRequest, stream recipepb.RecipeService_StreamFindServer) error {
+	req, err := findRequestFromProto(in)
+	if err != nil {
+		return grpcError(err)
+	}
+	if err := loadDataset(appConfig.DataPath); err != nil {
+		return grpcError(newAPIError(ErrDatasetUnavailable, err.Error()))
+	}
+
+	ctx, cancel := context.WithCancel(stream.Context())
+	defer cancel()
+	if appConfig.SearchTimeoutSeconds > 0 {
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(appConfig.SearchTimeoutSeconds)*time.Second)
+		defer cancel()
+	}
+	paths := make(chan PathResult)
+	errCh := make(chan error, 1)
+	go func() {
+		defer close(paths)
+		_, err := findRecipes(ctx, req, func(p PathResult) {
+			select {
+			case paths <- p:
+			case <-ctx.Done():
+			}
+		})
+		errCh <- err
+	}()
+
+	// paths is closed once the search stopped, a timeout or a failed send
+	// cancels it first
+	index := 0
+	var sendErr error
+	for p := range paths {
+		if sendErr != nil {
+			continue
+		}
+		index++
+		if sendErr = stream.Send(pathToProto(index, p)); sendErr != nil {
+			cancel()
+		}
+	}
+	err = <-errCh
+	switch {
+	case sendErr != nil:
+		return sendErr
+	case stream.Context().Err() != nil:
+		return stream.Context().Err()
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		return grpcError(newAPIError(ErrSearchTimeout, fmt.Sprintf("%d paths sent", index)))
+	case err != nil:
+		return grpcError(err)
+	}
+	return nil
+}
+
+func (s *recipeServer) GetElement(ctx context.Context, in *recipepb.GetElementRequest) (*recipepb.Element, error) {
+	if err := loadDataset(appConfig.DataPath); err != nil {
+		return nil, grpcError(newAPIError(ErrDatasetUnavailable, err.Error()))
+	}
+	acquirePacks(nil)
+	defer releasePacks()
+	info, ok := lookupElement(in.GetName())
+	if !ok {
+		return nil, grpcError(newAPIError(ErrUnknownElement, in.GetName()))
+	}
+	return elementToProto(info), nil
+}
+
+func (s *recipeServer) ListElements(ctx context.Context, in *recipepb.ListElementsRequest) (*recipepb.ListElementsResponse, error) {
+	if err := loadDataset(appConfig.DataPath); err != nil {
+		return nil, grpcError(newAPIError(ErrDatasetUnavailable, err.Error()))
+	}
+	acquirePacks(nil)
+	defer releasePacks()
+	var tier *int
+	if in.Tier != nil {
+		t := int(in.GetTier())
+		tier = &t
+	}
+	out := &recipepb.ListElementsResponse{}
+	for _, info := range listElements(in.GetPrefix(), tier) {
+		out.Elements = append(out.Elements, elementToProto(info))
+	}
+	return out, nil
+}
+
+// findRequestFromProto applies the same validation as /find
+func findRequestFromProto(in *recipepb.FindRequest) (FindRequest, error) {
+	count := int(in.GetCount())
+	if count == 0 {
+		count = 1
+	}
+	bidirectional := ""
+	if in.GetBidirectional() {
+		bidirectional = "true"
+	}
+	req, err := parseFindRequest(in.GetTarget(), in.GetMethod(), fmt.Sprint(count), bidirectional)
+	if err != nil {
+		return req, err
+	}
+	if packs := in.GetPacks(); len(packs) > 0 {
+		if req.Packs, err = parsePacks(strings.Join(packs, ",")); err != nil {
+			return req, err
+		}
+	}
+	if req.Objective, err = parseObjective(req.Method, in.GetOptimize(), in.GetWeights()); err != nil {
+		return req, err
+	}
+	if d := in.GetMaxDepth(); d != 0 {
+		if req.MaxDepth, err = parseMaxDepth(req.Method, fmt.Sprint(d)); err != nil {
+			return req, err
+		}
+	}
+	return req, nil
+}
+
+func pathToProto(index int, p PathResult) *recipepb.RecipePath {
+	out := &recipepb.RecipePath{
+		Index:        int32(index),
+		Runtime:      durationpb.New(p.Runtime),
+		NodesVisited: int32(p.NodesVisited),
+		Cost:         p.Cost,
+		Gap:          p.Gap,
+		Objective:    p.Objective,
+		NodesByDepth: depthNodesToProto(p.NodesByDepth),
+	}
+	for _, step := range p.Steps {
+		a, b, result, ok := parseStep(step)
+		if !ok {
+			continue
+		}
+		out.Steps = append(out.Steps, &recipepb.RecipeStep{Ingredient1: a, Ingredient2: b, Result: result})
+	}
+	return out
+}
+
+func depthNodesToProto(byDepth []DepthNodes) []*recipepb.DepthNodes {
+	var out []*recipepb.DepthNodes
+	for _, d := range byDepth {
+		out = append(out, &recipepb.DepthNodes{Depth: int32(d.Depth), Nodes: int32(d.Nodes)})
+	}
+	return out
+}
+
+func elementToProto(info ElementInfo) *recipepb.Element {
+	out := &recipepb.Element{
+		Name:   info.Name,
+		Tier:   int32(info.Tier),
+		UsedIn: info.UsedIn,
+	}
+	for _, r := range info.Recipes {
+		out.Recipes = append(out.Recipes, &recipepb.Ingredients{Ingredient1: r[0], Ingredient2: r[1]})
+	}
+	return out
+}
+
+// grpcError maps an APIError code onto the closest gRPC status code
+func grpcError(err error) error {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return status.Error(codes.Internal, err.Error())
+	}
+	code := codes.Internal
+	switch apiErr.Code {
+	case ErrTargetRequired, ErrMethodRequired, ErrInvalidMethod, ErrNumberRecipeRequired, ErrInvalidNumberRecipe, ErrInvalidTier, ErrInvalidPack, ErrInvalidSource, ErrInvalidObjective, ErrInvalidMaxDepth:
+		code = codes.InvalidArgument
+	case ErrUnknownElement, ErrScrapeJobNotFound, ErrImageNotFound:
+		code = codes.NotFound
+	case ErrScrapeInProgress:
+		code = codes.FailedPrecondition
+	case ErrSearchBudget:
+		code = codes.ResourceExhausted
+	case ErrSearchTimeout:
+		code = codes.DeadlineExceeded
+	case ErrDatasetUnavailable, ErrScrapeNetwork:
+		code = codes.Unavailable
+	case ErrUnauthorized:
+		code = codes.Unauthenticated
+	case ErrForbidden:
+		code = codes.PermissionDenied
+	}
+	return status.Error(code, apiErr.Error())
+}
+
+// grpcAuthorize applies the read-role check of requireRole to gRPC calls
+func grpcAuthorize(ctx context.Context, method string) error {
+	if !appConfig.RequireReadKey {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	token := ""
+	if v := md.Get("authorization"); len(v) > 0 && strings.HasPrefix(v[0], "Bearer ") {
+		token = strings.TrimSpace(strings.TrimPrefix(v[0], "Bearer "))
+	} else if v := md.Get("x-api-key"); len(v) > 0 {
+		token = v[0]
+	}
+
+	addr := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+	}
+	if token == "" {
+		log.Printf("[AUTH] Rejected gRPC %s from %s: missing API key\n", method, addr)
+		return grpcError(newAPIError(ErrUnauthorized, ""))
+	}
+	key, ok := lookupAPIKey(appConfig.APIKeys, token)
+	if !ok {
+		log.Printf("[AUTH] Rejected gRPC %s from %s: invalid API key\n", method, addr)
+		return grpcError(newAPIError(ErrUnauthorized, ""))
+	}
+	if !roleAllows(key.Role, RoleRead) {
+		log.Printf("[AUTH] Rejected gRPC %s from %s: key %q has role %s\n", method, addr, key.Name, key.Role)
+		return grpcError(newAPIError(ErrForbidden, ""))
+	}
+	return nil
+}
+
+func grpcAuthUnary(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if err := grpcAuthorize(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+	return handler(ctx, req)
+}
+
+func grpcAuthStream(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if err := grpcAuthorize(ss.Context(), info.FullMethod); err != nil {
+		return err
+	}
+	return handler(srv, ss)
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// politeTransport is the RoundTripper of every scrape collector. It retries
+// transient failures with capped exponential backoff and jitter, honours
+// Retry-After, and keeps GET responses in an on-disk cache that is
+// revalidated with ETag and Last-Modified.
+type politeTransport struct {
+	base     http.RoundTripper
+	cacheDir string // "" disables the cache
+	retries  int
+	baseWait time.Duration
+	maxWait  time.Duration
+	job      *ScrapeJob // progress counters, may be nil
+}
+
+// newPoliteTransport builds the transport from appConfig
+func newPoliteTransport(job *ScrapeJob) *politeTransport {
+	cacheDir := ""
+	if appConfig.HTTPCache {
+		cacheDir = filepath.Join(appConfig.CacheDir, "http")
+	}
+	return &politeTransport{
+		base: &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   30 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			TLSHandshakeTimeout:   15 * time.Second,
+			ResponseHeaderTimeout: 15 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		},
+		cacheDir: cacheDir,
+		retries:  appConfig.Retries,
+		baseWait: time.Duration(appConfig.RetryBaseMillis) * time.Millisecond,
+		maxWait:  time.Duration(appConfig.RetryMaxMillis) * time.Millisecond,
+		job:      job,
+	}
+}
+
+// cacheEntry is the metadata stored next to a cached body
+type cacheEntry struct {
+	URL          string      `json:"url"`
+	Status       int         `json:"status"`
+	Header       http.Header `json:"header"`
+	ETag         string      `json:"etag,omitempty"`
+	LastModified string      `json:"lastModified,omitempty"`
+	FetchedAt    time.Time   `json:"fetchedAt"`
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var cached *cacheEntry
+	cacheable := t.cacheDir != "" && req.Method == http.MethodGet
+	if cacheable {
+		cached = t.readEntry(req.URL.String())
+		if cached != nil {
+			req = req.Clone(req.Context())
+			if cached.ETag != "" {
+				req.Header.Set("If-None-Match", cached.ETag)
+			}
+			if cached.LastModified != "" {
+				req.Header.Set("If-Modified-Since", cached.LastModified)
+			}
+		}
+	}
+
+	resp, err := t.doWithRetries(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if cached != nil && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		body, err := os.ReadFile(t.cachePath(req.URL.String(), ".body"))
+		if err == nil {
+			t.job.cacheHit()
+			return cachedResponse(req, cached, body), nil
+		}
+		// The body went missing, fetch it again without validators
+		req.Header.Del("If-None-Match")
+		req.Header.Del("If-Modified-Since")
+		if resp, err = t.doWithRetries(req); err != nil {
+			return nil, err
+		}
+	}
+
+	if cacheable && resp.StatusCode == http.StatusOK &&
+		(resp.Header.Get("ETag") != "" || resp.Header.Get("Last-Modified") != "") {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		t.writeEntry(req.URL.String(), resp, body)
+		resp.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return resp, nil
+}
+
+// doWithRetries sends req, retrying network errors and 429/5xx answers
+func (t *politeTransport) doWithRetries(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		retry, reason := shouldRetry(resp, err)
+		if !retry || attempt >= t.retries {
+			return resp, err
+		}
+
+		wait := t.backoff(attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if after > t.maxWait {
+					// The server asks for a longer pause than we are willing to wait
+					return resp, nil
+				}
+				if after > wait {
+					wait = after
+				}
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		fmt.Printf("[SCRAPE] %s: %s, retry %d/%d in %s\n", req.URL, reason, attempt+1, t.retries, wait.Round(time.Millisecond))
+		t.job.retried()
+
+		select {
+		case <-time.After(wait):
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+	}
+}
+
+// backoff is full jitter over baseWait*2^attempt, capped at maxWait
+func (t *politeTransport) backoff(attempt int) time.Duration {
+	wait := t.baseWait << uint(attempt)
+	if wait > t.maxWait || wait <= 0 {
+		wait = t.maxWait
+	}
+	if wait <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(wait)) + 1)
+}
+
+// shouldRetry reports whether a failed attempt is transient
+func shouldRetry(resp *http.Response, err error) (bool, string) {
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			return false, ""
+		}
+		var netErr net.Error
+		if errors.As(err, &netErr) || isNetworkError(err) {
+			return true, err.Error()
+		}
+		return false, ""
+	}
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true, resp.Status
+	}
+	return false, ""
+}
+
+// retryAfter parses a Retry-After header, either seconds or an HTTP date
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(v); err == nil && secs >= 0 {
+		return time.Duration(secs) * time.Second, true
+	}
+	if at, err := http.ParseTime(v); err == nil {
+		if d := time.Until(at); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+func (t *politeTransport) cachePath(url, ext string) string {
+	sum := sha256.Sum256([]byte(url))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(t.cacheDir, key[:2], key+ext)
+}
+
+func (t *politeTransport) readEntry(url string) *cacheEntry {
+	data, err := os.ReadFile(t.cachePath(url, ".json"))
+	if err != nil {
+		return nil
+	}
+	var e cacheEntry
+	if err := json.Unmarshal(data, &e); err != nil || e.URL != url {
+		return nil
+	}
+	return &e
+}
+
+// writeEntry stores body first so a metadata file always has its body
+func (t *politeTransport) writeEntry(url string, resp *http.Response, body []byte) {
+	e := cacheEntry{
+		URL:          url,
+		Status:       resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}
+	meta, _ := json.Marshal(e)
+	if err := writeFileAtomic(t.cachePath(url, ".body"), body); err != nil {
+		fmt.Printf("[ERROR] Failed to cache %s: %v\n", url, err)
+		return
+	}
+	if err := writeFileAtomic(t.cachePath(url, ".json"), meta); err != nil {
+		fmt.Printf("[ERROR] Failed to cache %s: %v\n", url, err)
+	}
+}
+
+// cachedResponse rebuilds a 200 response from the cache
+func cachedResponse(req *http.Request, e *cacheEntry, body []byte) *http.Response {
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testTransport is a politeTransport to srv that does not wait between
+// retries
+func testTransport(srv *httptest.Server, cacheDir string, retries int) *politeTransport {
+	return &politeTransport{
+		base:     srv.Client().Transport,
+		cacheDir: cacheDir,
+		retries:  retries,
+		job:      &ScrapeJob{},
+	}
+}
+
+// transportGet sends a GET for url through t and returns the status and body
+func transportGet(t *testing.T, tr *politeTransport, url string) (int, string) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := tr.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode, string(body)
+}
+
+func TestPoliteTransportRetries(t *testing.T) {
+	tests := []struct {
+		name       string
+		failures   int // answers with status before the 200
+		status     int
+		retryAfter string
+		maxWait    time.Duration
+		want       int
+		requests   int32
+	}{
+		{name: "recovers", failures: 2, status: http.StatusServiceUnavailable, want: http.StatusOK, requests: 3},
+		{name: "gives up after the retries", failures: 10, status: http.StatusBadGateway, want: http.StatusBadGateway, requests: 4},
+		{name: "client errors are not retried", failures: 1, status: http.StatusNotFound, want: http.StatusNotFound, requests: 1},
+		{name: "honours a short Retry-After", failures: 1, status: http.StatusTooManyRequests, retryAfter: "0", want: http.StatusOK, requests: 2},
+		{name: "Retry-After above maxWait", failures: 1, status: http.StatusTooManyRequests, retryAfter: "3600", maxWait: time.Second, want: http.StatusTooManyRequests, requests: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(requests.Add(1)) <= tt.failures {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.status)
+					return
+				}
+				io.WriteString(w, "ok")
+			}))
+			defer srv.Close()
+
+			tr := testTransport(srv, "", 3)
+			tr.maxWait = tt.maxWait
+			status, _ := transportGet(t, tr, srv.URL)
+			if status != tt.want {
+				t.Errorf("status %d, want %d", status, tt.want)
+			}
+			if got := requests.Load(); got != tt.requests {
+				t.Errorf("%d requests, want %d", got, tt.requests)
+			}
+			if got := tr.job.Retries; got != int(tt.requests)-1 {
+				t.Errorf("job counted %d retries, want %d", got, tt.requests-1)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"", 0, false},
+		{"0", 0, true},
+		{"120", 2 * time.Minute, true},
+		{"-5", 0, false},
+		{"soon", 0, false},
+		{time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat), 0, true},
+	}
+	for _, tt := range tests {
+		got, ok := retryAfter(tt.value)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("retryAfter(%q) = %s, %v, want %s, %v", tt.value, got, ok, tt.want, tt.ok)
+		}
+	}
+
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	got, ok := retryAfter(future)
+	if !ok || got <= 58*time.Minute || got > time.Hour {
+		t.Errorf("retryAfter(%q) = %s, %v, want about an hour", future, got, ok)
+	}
+}
+
+func TestPoliteTransportRevalidates(t *testing.T) {
+	var full, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("ETag", `"v1"`)
+		io.WriteString(w, "<table>recipes</table>")
+	}))
+	defer srv.Close()
+
+	tr := testTransport(srv, t.TempDir(), 0)
+	for i := 0; i < 3; i++ {
+		status, body := transportGet(t, tr, srv.URL)
+		if status != http.StatusOK || body != "<table>recipes</table>" {
+			t.Fatalf("request %d: %d %q", i, status, body)
+		}
+	}
+	if full.Load() != 1 || notModified.Load() != 2 {
+		t.Errorf("%d full answers and %d 304s, want 1 and 2", full.Load(), notModified.Load())
+	}
+	if tr.job.CachedPages != 2 {
+		t.Errorf("job counted %d cached pages, want 2", tr.job.CachedPages)
+	}
+
+	// A cache entry without its body is fetched again in full
+	if err := os.Remove(tr.cachePath(srv.URL, ".body")); err != nil {
+		t.Fatal(err)
+	}
+	status, body := transportGet(t, tr, srv.URL)
+	if status != http.StatusOK || body != "<table>recipes</table>" {
+		t.Fatalf("after losing the body: %d %q", status, body)
+	}
+	if full.Load() != 2 {
+		t.Errorf("%d full answers, want the body fetched again", full.Load())
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"strconv"
+	"time"
+)
+
+// DepthNodes is the number of nodes one iteration of iddfs visited with
+// its depth limit
+type DepthNodes struct {
+	Depth int `json:"depth"`
+	Nodes int `json:"nodes"`
+}
+
+// iddfsFailure is the highest limit an element failed at in one
+// iteration, and whether that search ran into the limit somewhere; without
+// a cutoff the element cannot be made at any limit
+type iddfsFailure struct {
+	limit  int
+	cutoff bool
+}
+
+// iddfsTree returns the steps of a recipe tree of element at most limit
+// combinations deep, ingredients before results, and whether the search
+// was cut off by the limit. failed keeps the failures of this iteration:
+// an element fails at any lower limit too, so a subtree shared by several
+// recipes is not searched twice.
+func iddfsTree(ctx context.Context, element string, limit int, failed map[string]iddfsFailure, nodes *int) ([]string, bool, bool) {
+	*nodes++
+	if ctx.Err() != nil {
+		return nil, false, true
+	}
+	if baseElements[element] {
+		return []string{}, true, false
+	}
+	if limit == 0 {
+		return nil, false, true
+	}
+	if f, ok := failed[element]; ok && f.limit >= limit {
+		return nil, false, f.cutoff
+	}
+	cutoff := false
+	for _, r := range validRecipes(element) {
+		left, ok, cut := iddfsTree(ctx, r.a, limit-1, failed, nodes)
+		cutoff = cutoff || cut
+		if !ok {
+			continue
+		}
+		right, ok, cut := iddfsTree(ctx, r.b, limit-1, failed, nodes)
+		cutoff = cutoff || cut
+		if !ok {
+			continue
+		}
+		steps := append(left, right...)
+		return append(steps, r.step()), true, false
+	}
+	failed[element] = iddfsFailure{limit: limit, cutoff: cutoff}
+	return nil, false, cutoff
+}
+
+// iddfsPath runs a depth-limited DFS for target with limits 1, 2, ... up
+// to maxDepth, so the recipe it returns is one of the shallowest. Memory
+// stays that of a single DFS. It stops early once an iteration fails
+// without reaching its limit, deeper ones would not find more; a recipe
+// chain never repeats an element, so that happens by the number of
+// elements. nodesByDepth has the nodes visited by every iteration.
+func iddfsPath(ctx context.Context, target string, maxDepth int) ([]string, bool, time.Duration, int, []DepthNodes) {
+	start := time.Now()
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	total := 0
+	nodesByDepth := []DepthNodes{}
+	if baseElements[target] {
+		return []string{}, true, time.Since(start), 0, nodesByDepth
+	}
+	maxDepth = min(maxDepth, len(recipesMap)+1)
+	for limit := 1; limit <= maxDepth && ctx.Err() == nil; limit++ {
+		nodes := 0
+		steps, ok, cutoff := iddfsTree(ctx, target, limit, make(map[string]iddfsFailure), &nodes)
+		total += nodes
+		nodesByDepth = append(nodesByDepth, DepthNodes{Depth: limit, Nodes: nodes})
+		if ok {
+			return steps, true, time.Since(start), total, nodesByDepth
+		}
+		if !cutoff {
+			break
+		}
+	}
+	return nil, false, time.Since(start), total, nodesByDepth
+}
+
+// parseMaxDepth validates the maxDepth value of a request for method, ""
+// is the configured maxDepth
+func parseMaxDepth(method, value string) (int, error) {
+	if value == "" {
+		return 0, nil
+	}
+	if method != "iddfs" {
+		return 0, newAPIError(ErrInvalidMaxDepth, "maxDepth only applies to method iddfs")
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, newAPIError(ErrInvalidMaxDepth, value)
+	}
+	return n, nil
+}
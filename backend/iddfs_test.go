@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// stepsDepth is the depth of the recipe tree the steps build, base
+// elements have depth 0
+func stepsDepth(t *testing.T, target string, steps []string) int {
+	t.Helper()
+	depth := make(map[string]int)
+	for _, step := range steps {
+		a, b, result, ok := parseStep(step)
+		if !ok {
+			t.Fatalf("malformed step %q", step)
+		}
+		depth[result] = 1 + max(depth[a], depth[b])
+	}
+	return depth[target]
+}
+
+func TestIDDFSFindsShallowestTree(t *testing.T) {
+	useGraph(t, smallGraph)
+	trees := bruteTrees()
+	obj := Objective{Name: OptimizeDepth}
+	ctx := context.Background()
+	for _, e := range craftedElements() {
+		want := int(bruteMin(trees[e], func(b *bruteTree) float64 { return b.cost(obj) }))
+
+		steps, found, _, nodes, byDepth := iddfsPath(ctx, e, 100)
+		if !found {
+			t.Fatalf("%s: not found", e)
+		}
+		if err := validatePath(e, steps); err != nil {
+			t.Errorf("%s: %v", e, err)
+		}
+		if got := stepsDepth(t, e, steps); got != want {
+			t.Errorf("%s: tree depth %d, brute force min depth %d", e, got, want)
+		}
+		if len(byDepth) != want || byDepth[len(byDepth)-1].Depth != want {
+			t.Errorf("%s: iterations %v, want limits 1 to %d", e, byDepth, want)
+		}
+		sum := 0
+		for _, d := range byDepth {
+			sum += d.Nodes
+		}
+		if sum != nodes {
+			t.Errorf("%s: nodes by depth sum to %d, %d nodes visited", e, sum, nodes)
+		}
+
+		if want > 1 {
+			_, found, _, _, byDepth := iddfsPath(ctx, e, want-1)
+			if found {
+				t.Errorf("%s: found a tree within depth %d, brute force min depth %d", e, want-1, want)
+			}
+			if len(byDepth) != want-1 {
+				t.Errorf("%s: maxDepth %d ran iterations %v", e, want-1, byDepth)
+			}
+		}
+	}
+}
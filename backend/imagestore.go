@@ -0,0 +1,354 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gocolly/colly"
+)
+
+// StoredImage is the icon of one element in the image store. Files are
+// content-addressed: the same icon is stored once, under its sha256.
+type StoredImage struct {
+	Element     string `json:"element"`
+	Hash        string `json:"hash"`
+	ContentType string `json:"contentType"`
+	SourceURL   string `json:"sourceUrl,omitempty"`
+}
+
+// ImageReport lists the elements of the dataset without an icon and the
+// icons that belong to no element
+type ImageReport struct {
+	Elements int      `json:"elements"`
+	Icons    int      `json:"icons"`
+	Missing  []string `json:"missing"`
+	Orphans  []string `json:"orphans"`
+}
+
+// imageIndexPath is the element -> image index of a store
+func imageIndexPath(dir string) string {
+	return filepath.Join(dir, "index.json")
+}
+
+// imageExt picks the file extension of a stored image
+func imageExt(contentType string) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch mediaType {
+	case "image/svg+xml":
+		return ".svg"
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	}
+	return ".img"
+}
+
+// storedImagePath is where the image with hash lives in the store
+func storedImagePath(dir string, img StoredImage) string {
+	return filepath.Join(dir, img.Hash[:2], img.Hash+imageExt(img.ContentType))
+}
+
+// storeImage writes data into the store unless it is already there and
+// returns its hash
+func storeImage(dir string, data []byte, contentType string) (string, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	path := storedImagePath(dir, StoredImage{Hash: hash, ContentType: contentType})
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	return hash, writeFileAtomic(path, data)
+}
+
+// loadImageIndex reads the index of the store in dir. A missing index is
+// an empty store.
+func loadImageIndex(dir string) (map[string]StoredImage, error) {
+	out := make(map[string]StoredImage)
+	if dir == "" {
+		return out, nil
+	}
+	data, err := os.ReadFile(imageIndexPath(dir))
+	if os.IsNotExist(err) {
+		return out, nil
+	}
+	if err != nil {
+		return out, err
+	}
+	var images []StoredImage
+	if err := json.Unmarshal(data, &images); err != nil {
+		return out, err
+	}
+	for _, img := range images {
+		if !validImageHash(img.Hash) {
+			fmt.Printf("[ERROR] Skipping image of %q in %s: hash %q is not a sha256\n", img.Element, imageIndexPath(dir), img.Hash)
+			continue
+		}
+		out[strings.ToLower(img.Element)] = img
+	}
+	return out, nil
+}
+
+// validImageHash reports whether hash is what storeImage names a file
+// by, so storedImagePath stays inside the store
+func validImageHash(hash string) bool {
+	if len(hash) != 2*sha256.Size {
+		return false
+	}
+	_, err := hex.DecodeString(hash)
+	return err == nil
+}
+
+// saveImageIndex atomically writes the index of the store in dir
+func saveImageIndex(dir string, index map[string]StoredImage) error {
+	images := make([]StoredImage, 0, len(index))
+	for _, name := range sortedKeys(index) {
+		images = append(images, index[name])
+	}
+	data, err := json.MarshalIndent(images, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(imageIndexPath(dir), data)
+}
+
+// downloadElementImages stores the icon of every element of details that
+// has an ImageURL and returns the updated index of the store in dir.
+// Downloads share the rate limit and cache of the deep crawl.
+func downloadElementImages(ctx context.Context, dir string, details []ElementDetails, job *ScrapeJob) map[string]StoredImage {
+	index, err := loadImageIndex(dir)
+	if err != nil {
+		job.addError(fmt.Sprintf("image index: %v", err))
+		index = make(map[string]StoredImage)
+	}
+
+	// Several elements can share an icon, fetch each URL once
+	byURL := make(map[string][]string)
+	for _, d := range details {
+		if d.ImageURL != "" {
+			byURL[d.ImageURL] = append(byURL[d.ImageURL], d.Element)
+		}
+	}
+
+	c := colly.NewCollector(colly.Async(true))
+	c.IgnoreRobotsTxt = false
+	c.WithTransport(&contextTransport{ctx: ctx, base: newPoliteTransport(job)})
+	_ = c.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Parallelism: appConfig.CrawlParallelism,
+		Delay:       time.Duration(appConfig.CrawlDelayMillis) * time.Millisecond,
+		RandomDelay: time.Duration(appConfig.CrawlDelayMillis) * time.Millisecond / 2,
+	})
+
+	var mu sync.Mutex
+	stored := 0
+	c.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+		}
+	})
+	c.OnResponse(func(r *colly.Response) {
+		job.pageFetched()
+		url := r.Ctx.Get("url")
+		contentType := r.Headers.Get("Content-Type")
+		if !strings.HasPrefix(contentType, "image/") {
+			job.addError(fmt.Sprintf("%s: not an image (%s)", url, contentType))
+			return
+		}
+		hash, err := storeImage(dir, r.Body, contentType)
+		if err != nil {
+			job.addError(fmt.Sprintf("%s: %v", url, err))
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, element := range byURL[url] {
+			index[element] = StoredImage{Element: element, Hash: hash, ContentType: contentType, SourceURL: url}
+			stored++
+		}
+	})
+	c.OnError(func(r *colly.Response, e error) {
+		job.addError(fmt.Sprintf("%s: %v", r.Request.URL, e))
+	})
+
+	for _, url := range sortedKeys(byURL) {
+		if ctx.Err() != nil {
+			break
+		}
+		cctx := colly.NewContext()
+		cctx.Put("url", url)
+		if err := c.Request("GET", url, nil, cctx, nil); err != nil {
+			job.addError(fmt.Sprintf("%s: %v", url, err))
+		}
+	}
+	c.Wait()
+
+	fmt.Printf("[DEBUG] Stored icons of %d of %d elements\n", stored, len(details))
+	return index
+}
+
+// checkImages compares the elements of the dataset with the store in dir.
+// Orphans are stored files no element points to, and index entries of
+// elements that are not in the dataset.
+func checkImages(elements []string, dir string, index map[string]StoredImage) ImageReport {
+	report := ImageReport{Elements: len(elements), Missing: []string{}, Orphans: []string{}}
+	known := make(map[string]bool, len(elements))
+	for _, name := range elements {
+		known[name] = true
+		if _, ok := index[name]; !ok {
+			report.Missing = append(report.Missing, name)
+		}
+	}
+
+	used := make(map[string]bool)
+	for _, name := range sortedKeys(index) {
+		img := index[name]
+		used[filepath.Base(storedImagePath(dir, img))] = true
+		if !known[name] {
+			report.Orphans = append(report.Orphans, "element "+name)
+		}
+	}
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path == imageIndexPath(dir) {
+			return nil
+		}
+		report.Icons++
+		if !used[d.Name()] {
+			report.Orphans = append(report.Orphans, "file "+path)
+		}
+		return nil
+	})
+	return report
+}
+
+// mapImageDir finds the icon of every element in a folder of *_2.svg files
+// named after the elements (spaces as underscores), the layout of the
+// frontend's public/images. It returns element -> file name and a report.
+func mapImageDir(elements []string, dir string) (map[string]string, ImageReport, error) {
+	files := make(map[string]string)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() && strings.HasSuffix(d.Name(), "_2.svg") {
+			files[strings.ToLower(strings.TrimSuffix(d.Name(), "_2.svg"))] = d.Name()
+		}
+		return nil
+	})
+	report := ImageReport{Elements: len(elements), Icons: len(files), Missing: []string{}, Orphans: []string{}}
+	if err != nil {
+		return nil, report, err
+	}
+
+	mapping := make(map[string]string)
+	used := make(map[string]bool)
+	for _, name := range elements {
+		key := strings.ReplaceAll(name, " ", "_")
+		if file, ok := files[key]; ok {
+			mapping[name] = file
+			used[key] = true
+		} else {
+			report.Missing = append(report.Missing, name)
+		}
+	}
+	for _, key := range sortedKeys(files) {
+		if !used[key] {
+			report.Orphans = append(report.Orphans, files[key])
+		}
+	}
+	return mapping, report, nil
+}
+
+// saveImageMap writes mapping in the mapped_elements.json format read by
+// loadElementImages and the frontend
+func saveImageMap(path string, mapping map[string]string) error {
+	type mapped struct {
+		Element      string `json:"Element"`
+		ElementImage string `json:"ElementImage"`
+	}
+	out := make([]mapped, 0, len(mapping))
+	for _, name := range sortedKeys(mapping) {
+		out = append(out, mapped{Element: name, ElementImage: mapping[name]})
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data)
+}
+
+// datasetElements returns every element of recipes and the base elements,
+// sorted
+func datasetElements(recipes []Recipe) []string {
+	names := recipeElements(recipes)
+	out := make([]string, 0, len(names))
+	for name := range names {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ElementImageHandler handles GET /elements/:name/image. Icons of the image
+// store are served directly, icons of the mapped_elements.json folder are a
+// redirect to imageBaseURL.
+func ElementImageHandler(c *gin.Context) {
+	if err := loadDataset(appConfig.DataPath); err != nil {
+		respondError(c, newAPIError(ErrDatasetUnavailable, err.Error()))
+		return
+	}
+	mutex.RLock()
+	name := resolveAlias(strings.ToLower(strings.TrimSpace(c.Param("name"))))
+	img, stored := storedImages[name]
+	legacy := elementImages[name]
+	mutex.RUnlock()
+
+	switch {
+	case stored:
+		etag := `"` + img.Hash + `"`
+		c.Header("ETag", etag)
+		c.Header("Cache-Control", "public, max-age=86400")
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Header("Content-Type", img.ContentType)
+		c.File(storedImagePath(appConfig.ImageStore, img))
+	case legacy != "":
+		c.Redirect(http.StatusFound, appConfig.ImageBaseURL+legacy)
+	default:
+		if _, ok := lookupElement(name); ok {
+			respondError(c, newAPIError(ErrImageNotFound, name))
+			return
+		}
+		respondError(c, newAPIError(ErrUnknownElement, c.Param("name")))
+	}
+}
+
+// ImageReportHandler handles GET /admin/images
+func ImageReportHandler(c *gin.Context) {
+	if err := loadDataset(appConfig.DataPath); err != nil {
+		respondError(c, newAPIError(ErrDatasetUnavailable, err.Error()))
+		return
+	}
+	mutex.RLock()
+	defer mutex.RUnlock()
+	c.JSON(http.StatusOK, checkImages(datasetElements(loadedRecipes), appConfig.ImageStore, storedImages))
+}
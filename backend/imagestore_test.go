@@ -0,0 +1,45 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadImageIndexSkipsBadHashes(t *testing.T) {
+	dir := t.TempDir()
+	hash, err := storeImage(dir, []byte("<svg/>"), "image/svg+xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	index := map[string]StoredImage{
+		"steam": {Element: "steam", Hash: hash, ContentType: "image/svg+xml"},
+		"brick": {Element: "brick", Hash: "a", ContentType: "image/svg+xml"},
+		"mud":   {Element: "mud", Hash: "", ContentType: "image/svg+xml"},
+		"stone": {Element: "stone", Hash: "../../etc/passwd", ContentType: "image/svg+xml"},
+		"lava":  {Element: "lava", Hash: strings.Repeat("zz", 32), ContentType: "image/svg+xml"},
+	}
+	if err := saveImageIndex(dir, index); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := loadImageIndex(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 1 {
+		t.Errorf("want only steam, got %v", sortedKeys(got))
+	}
+	img, ok := got["steam"]
+	if !ok {
+		t.Fatal("the stored image was skipped")
+	}
+	path := storedImagePath(dir, img)
+	if rel, err := filepath.Rel(dir, path); err != nil || strings.HasPrefix(rel, "..") {
+		t.Errorf("%s is outside the store %s", path, dir)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error(err)
+	}
+}
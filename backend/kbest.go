@@ -0,0 +1,176 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"time"
+)
+
+// derivation is one recipe tree of an element: the recipe at its root and
+// the rank of the subtree used for each ingredient. Base elements have one
+// derivation without recipe.
+type derivation struct {
+	recipe      *solverRecipe
+	left, right int
+	cost        float64
+}
+
+// candidate is a derivation not yet known to be the next best of its
+// element
+type candidate struct {
+	recipe      solverRecipe
+	left, right int
+	cost        float64
+}
+
+// candidateQueue is a min-heap of candidates by cost. Ties go by recipe and
+// ranks so the order does not depend on map order.
+type candidateQueue []candidate
+
+func (q candidateQueue) Len() int { return len(q) }
+func (q candidateQueue) Less(i, j int) bool {
+	a, b := q[i], q[j]
+	if a.cost != b.cost {
+		return a.cost < b.cost
+	}
+	if a.recipe != b.recipe {
+		return a.recipe.a < b.recipe.a || (a.recipe.a == b.recipe.a && a.recipe.b < b.recipe.b)
+	}
+	if a.left != b.left {
+		return a.left < b.left
+	}
+	return a.right < b.right
+}
+func (q candidateQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *candidateQueue) Push(x interface{}) { *q = append(*q, x.(candidate)) }
+func (q *candidateQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+// kBest enumerates the recipe trees of every element in non-decreasing
+// cost, lazily: an element keeps the trees found so far (found) and a
+// queue of candidates. A candidate is a recipe with a rank for each
+// ingredient; when it is taken, the candidates with one rank moved up by
+// one are queued, so the cross product of the ingredient trees is never
+// built. This is the lazy k-best algorithm of Huang and Chiang (2005) over
+// the recipe graph; it needs a cost that never decreases when a subtree
+// gets more expensive, which every tree objective is.
+type kBest struct {
+	ctx     context.Context
+	obj     Objective
+	found   map[string][]derivation
+	queue   map[string]*candidateQueue
+	queued  map[candidate]bool
+	started map[string]bool
+	// taken counts the trees taken from the queues, over all elements
+	taken int
+}
+
+func newKBest(ctx context.Context, obj Objective) *kBest {
+	return &kBest{
+		ctx:     ctx,
+		obj:     obj,
+		found:   make(map[string][]derivation),
+		queue:   make(map[string]*candidateQueue),
+		queued:  make(map[candidate]bool),
+		started: make(map[string]bool),
+	}
+}
+
+// push queues the candidate r with ranks left and right if both subtrees
+// exist. Both ingredients the same, only left <= right is queued: the
+// swapped ranks are the same tree.
+func (k *kBest) push(element string, r solverRecipe, left, right int) {
+	if r.a == r.b && left > right {
+		return
+	}
+	a, okA := k.kth(r.a, left)
+	b, okB := k.kth(r.b, right)
+	if !okA || !okB {
+		return
+	}
+	c := candidate{recipe: r, left: left, right: right, cost: k.obj.combine(element, a.cost, b.cost)}
+	if k.queued[c] {
+		return
+	}
+	k.queued[c] = true
+	heap.Push(k.queue[element], c)
+}
+
+// kth returns the n-th best tree of element (from 0), computing it and the
+// trees before it when needed. It gives up when ctx is done. The caller
+// holds mutex.
+func (k *kBest) kth(element string, n int) (derivation, bool) {
+	if baseElements[element] {
+		if n == 0 {
+			return derivation{cost: k.obj.baseCost(element)}, true
+		}
+		return derivation{}, false
+	}
+	if !k.started[element] {
+		k.started[element] = true
+		k.queue[element] = &candidateQueue{}
+		for _, r := range validRecipes(element) {
+			k.push(element, r, 0, 0)
+		}
+	}
+	for len(k.found[element]) <= n {
+		q := k.queue[element]
+		if q.Len() == 0 || k.ctx.Err() != nil {
+			return derivation{}, false
+		}
+		c := heap.Pop(q).(candidate)
+		r := c.recipe
+		k.found[element] = append(k.found[element], derivation{recipe: &r, left: c.left, right: c.right, cost: c.cost})
+		k.taken++
+		k.push(element, r, c.left+1, c.right)
+		k.push(element, r, c.left, c.right+1)
+	}
+	return k.found[element][n], true
+}
+
+// steps lists the n-th best tree of element, ingredients before results
+func (k *kBest) steps(element string, n int) []string {
+	steps := []string{}
+	var build func(element string, n int)
+	build = func(element string, n int) {
+		if baseElements[element] {
+			return
+		}
+		d := k.found[element][n]
+		build(d.recipe.a, d.left)
+		build(d.recipe.b, d.right)
+		steps = append(steps, d.recipe.step())
+	}
+	build(element, n)
+	return steps
+}
+
+// kBestPaths returns up to count distinct recipe trees of target in
+// non-decreasing obj cost, the best first. Runtime of a path is the time
+// until it was found, NodesVisited the trees taken from the queues until
+// then. When ctx is done first it returns the trees found so far.
+func kBestPaths(ctx context.Context, target string, count int, obj Objective) ([]PathResult, time.Duration, int) {
+	start := time.Now()
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	k := newKBest(ctx, obj)
+	var paths []PathResult
+	for n := 0; n < count; n++ {
+		d, ok := k.kth(target, n)
+		if !ok {
+			if ctx.Err() != nil {
+				fmt.Printf("[DEBUG] kbest stopped after %d of %d paths: %v\n", n, count, ctx.Err())
+			}
+			break
+		}
+		cost := d.cost
+		paths = append(paths, PathResult{Steps: k.steps(target, n), Runtime: time.Since(start), NodesVisited: k.taken, Cost: &cost, Objective: obj.Name})
+	}
+	return paths, time.Since(start), k.taken
+}
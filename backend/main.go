@@ -5,14 +5,10 @@ import (
 	// "log"
 	// "os"
 	// "time"
-	"encoding/json"
-	"fmt"
 	"log"
 	"os"
-	"runtime"
 	"strconv"
-	"strings"
-	"sync"
+	"time"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
@@ -20,170 +16,178 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		if cmd, ok := cliCommands[os.Args[1]]; ok {
+			os.Exit(cmd(os.Args[2:]))
+		}
+	}
+	runServer(os.Args[1:])
+}
+
+// runServer starts the HTTP (and gRPC) API, args are the config flags
+func runServer(args []string) {
+	cfg, err := loadConfig(args)
+	if err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
+	applyConfig(cfg)
+	r := newRouter(cfg)
+
+	if !hasAdminKey(cfg) {
+		log.Println("[AUTH] No admin API key configured, /admin routes are disabled")
+	}
+	if cfg.GRPCPort != "" {
+		go func() {
+			log.Fatal(startGRPCServer(cfg))
+		}()
+	}
+	log.Printf("Listening on 0.0.0.0:%s\n", cfg.Port)
+	log.Fatal(r.Run("0.0.0.0:" + cfg.Port))
+}
+
+// newRouter builds the HTTP routes, the public group needs the read role
+// and the admin group the admin role
+func newRouter(cfg *Config) *gin.Engine {
 	r := gin.Default()
-	r.Use(cors.Default())
+	r.Use(corsMiddleware(cfg))
 
 	r.GET("/ping", func(c *gin.Context) {
 		c.JSON(200, gin.H{"message": "pong"})
 	})
-	r.GET("/scrape", ScrapeHandler)
-	r.GET("/find", func(c *gin.Context) {
-		target := c.Query("target")
-		if target == "" {
-			c.JSON(400, gin.H{"error": "Target tidak boleh kosong"})
+
+	public := r.Group("/", requireRole(RoleRead))
+	public.GET("/recipes", func(c *gin.Context) {
+		patched, err := loadPatchedRecipes(appConfig.DataPath)
+		if err != nil {
+			respondError(c, newAPIError(ErrDatasetUnavailable, err.Error()))
+			return
+		}
+		c.JSON(200, gin.H{"data": patched.recipes})
+	})
+	public.GET("/elements", func(c *gin.Context) {
+		if err := loadDataset(appConfig.DataPath); err != nil {
+			respondError(c, newAPIError(ErrDatasetUnavailable, err.Error()))
 			return
 		}
-		method := c.Query("method")
-		if method == "" {
-			c.JSON(400, gin.H{"error": "Method tidak boleh kosong"})
+		acquirePacks(nil)
+		defer releasePacks()
+		var tier *int
+		if t := c.Query("tier"); t != "" {
+			n, err := strconv.Atoi(t)
+			if err != nil {
+				respondError(c, newAPIError(ErrInvalidTier, t))
+				return
+			}
+			tier = &n
+		}
+		c.JSON(200, listElements(c.Query("prefix"), tier))
+	})
+	public.GET("/elements/:name", func(c *gin.Context) {
+		if err := loadDataset(appConfig.DataPath); err != nil {
+			respondError(c, newAPIError(ErrDatasetUnavailable, err.Error()))
 			return
 		}
-
-		if method != "bfs" && method != "dfs" {
-			c.JSON(400, gin.H{"error": "Method tidak valid"})
+		acquirePacks(nil)
+		defer releasePacks()
+		info, ok := lookupElement(c.Param("name"))
+		if !ok {
+			respondError(c, newAPIError(ErrUnknownElement, c.Param("name")))
 			return
 		}
-
-		numberRecipe := c.Query("numberRecipe")
-		if numberRecipe == "" {
-			c.JSON(400, gin.H{"error": "Number recipe tidak boleh kosong"})
+		c.JSON(200, info)
+	})
+	public.GET("/elements/:name/metrics", func(c *gin.Context) {
+		if err := loadDataset(appConfig.DataPath); err != nil {
+			respondError(c, newAPIError(ErrDatasetUnavailable, err.Error()))
 			return
 		}
-
-		recipes, err := loadRecipes("data/recipes.json")
+		var packs []string
+		if list, ok := c.GetQuery("packs"); ok {
+			var err error
+			if packs, err = parsePacks(list); err != nil {
+				respondError(c, err)
+				return
+			}
+		}
+		acquirePacks(packs)
+		defer releasePacks()
+		metrics, err := elementMetrics(c.Param("name"), splitList(c.Query("exclude")))
 		if err != nil {
-			c.JSON(500, gin.H{"error": "Error loading recipes: " + err.Error()})
+			respondError(c, err)
 			return
 		}
-		buildRecipeMap(recipes)
-
-		numberRecipeInt, err := strconv.Atoi(numberRecipe)
-		var maxPathsPerTarget = numberRecipeInt
+		c.JSON(200, metrics)
+	})
+	public.GET("/elements/:name/image", ElementImageHandler)
+	public.GET("/graphql", GraphQLHandler)
+	public.POST("/graphql", GraphQLHandler)
+	public.GET("/find", func(c *gin.Context) {
+		req, err := parseFindRequest(c.Query("target"), c.Query("method"), c.Query("numberRecipe"), c.Query("bidirectional"))
 		if err != nil {
-			c.JSON(400, gin.H{"error": "Invalid numberRecipe value"})
+			respondError(c, err)
 			return
 		}
-		if numberRecipeInt == 1 {
-			if method == "bfs" {
-				if bidirectional := c.Query("bidirectional"); bidirectional == "true" {
-					steps, ok, runtime, nodesVisited := bfsBidirectionalPath(strings.ToLower(target))
-					result := Result{
-						Found:        ok,
-						Steps:        steps,
-						Runtime:      runtime.String(),
-						NodesVisited: nodesVisited,
-					}
-					jsonResult, _ := json.Marshal(result)
-					c.Data(200, "application/json", jsonResult)
-					return
-				} else {
-					steps, ok, runtimes, nodes := bfsSinglePath(strings.ToLower(target))
-					result := Result{
-						Found:        ok,
-						Steps:        steps,
-						Runtime:      runtimes.String(),
-						NodesVisited: nodes,
-					}
-					jsonResult, _ := json.Marshal(result)
-					c.Data(200, "application/json", jsonResult)
-					return
-				}
-			} else if method == "dfs" {
-				if bidirectional := c.Query("bidirectional"); bidirectional == "true" {
-					steps, ok, runtime, nodesVisited := dfsBidirectionalPath(strings.ToLower(target))
-					result := Result{
-						Found:        ok,
-						Steps:        steps,
-						Runtime:      runtime.String(),
-						NodesVisited: nodesVisited,
-					}
-					jsonResult, _ := json.Marshal(result)
-					c.Data(200, "application/json", jsonResult)
-					return
-				} else {
-					steps, ok, runtime, nodesVisited := DFSWrapper(strings.ToLower(target))
-					result := Result{
-						Found:        ok,
-						Steps:        steps,
-						Runtime:      runtime.String(),
-						NodesVisited: nodesVisited,
-					}
-					jsonResult, _ := json.Marshal(result)
-					c.Data(200, "application/json", jsonResult)
-					return
-				}
-			}
-		} else {
-			if method == "bfs" {
-				// Buat worker pool untuk BFS multiple paths
-
-				bfsResults, found, runtime, nodes := bfsMultiplePaths(target, maxPathsPerTarget)
-
-				resultsJSON := make([]map[string][]string, 0)
-				if found {
-					for i, path := range bfsResults {
-						pathJSON := make(map[string][]string)
-						pathJSON[fmt.Sprintf("Path %d", i+1)] = path
-						resultsJSON = append(resultsJSON, pathJSON)
-						resultsJSON[i]["Runtime"] = []string{runtime.String()}
-						resultsJSON[i]["NodesVisited"] = []string{strconv.Itoa(nodes)}
-					}
-				}
-				//
-				jsonResult, _ := json.Marshal(resultsJSON)
-				c.Data(200, "application/json", jsonResult)
+		if packs, ok := c.GetQuery("packs"); ok {
+			if req.Packs, err = parsePacks(packs); err != nil {
+				respondError(c, err)
 				return
-			} else if method == "dfs" {
-				jobs := make(chan Job)
-				results := make(chan JobResultDFS)
-				var wg sync.WaitGroup
-				maxResults = numberRecipeInt
-
-				numWorkers := runtime.NumCPU()
-				for i := 0; i < numWorkers; i++ {
-					wg.Add(1)
-					go worker(i, jobs, results, &wg)
-				}
+			}
+		}
+		if req.Objective, err = parseObjective(req.Method, c.Query("optimize"), c.Query("weights")); err != nil {
+			respondError(c, err)
+			return
+		}
+		if req.MaxDepth, err = parseMaxDepth(req.Method, c.Query("maxDepth")); err != nil {
+			respondError(c, err)
+			return
+		}
 
-				go func() {
-					for i := range recipesMap[target] {
-						jobs <- Job{JobID: i + 1, JobType: "dfs", Target: target}
-					}
-					close(jobs)
-				}()
+		if err := loadDataset(appConfig.DataPath); err != nil {
+			respondError(c, newAPIError(ErrDatasetUnavailable, err.Error()))
+			return
+		}
 
-				go func() {
-					wg.Wait()
-					close(results)
-				}()
+		result, err := findWithTimeout(c.Request.Context(), req, time.Duration(appConfig.SearchTimeoutSeconds)*time.Second)
+		if err != nil {
+			respondError(c, err)
+			return
+		}
+		c.JSON(200, result.legacyJSON(req))
+	})
 
-				printed := 0
-				seenPaths := make(map[string]bool)
-				var resultsJSON []map[string][]string // Ubah dari []Result ke []map[string][]string
-				for res := range results {
-					key := strings.Join(res.Steps, "|")
-					if !seenPaths[key] {
-						seenPaths[key] = true
-						pathJSON := make(map[string][]string)
-						pathJSON[fmt.Sprintf("Path %d", printed+1)] = res.Steps
-						pathJSON["Runtime"] = []string{res.Duration.String()}
-						pathJSON["NodesVisited"] = []string{strconv.Itoa(res.NodesVisited)}
-						resultsJSON = append(resultsJSON, pathJSON)
-						printed++
-						if printed >= maxResults {
-							break
-						}
-					}
-				}
-				jsonResult, _ := json.Marshal(resultsJSON)
-				c.Data(200, "application/json", jsonResult)
-			}
+	admin := r.Group("/admin", requireRole(RoleAdmin))
+	admin.POST("/scrape", StartScrapeHandler)
+	admin.GET("/scrape", ListScrapeJobsHandler)
+	admin.GET("/scrape/:id", ScrapeJobHandler)
+	admin.DELETE("/scrape/:id", CancelScrapeJobHandler)
+	admin.GET("/sources", ListSourcesHandler)
+	public.GET("/packs", func(c *gin.Context) {
+		c.JSON(200, gin.H{"default": appConfig.DefaultPacks, "packs": appConfig.Packs})
+	})
+	admin.GET("/overrides", func(c *gin.Context) {
+		if err := loadDataset(appConfig.DataPath); err != nil {
+			respondError(c, newAPIError(ErrDatasetUnavailable, err.Error()))
+			return
 		}
+		mutex.RLock()
+		defer mutex.RUnlock()
+		c.JSON(200, overrideReport)
+	})
+	admin.GET("/images", ImageReportHandler)
+	admin.GET("/config", func(c *gin.Context) {
+		c.JSON(200, appConfig.Redacted())
 	})
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080"
+	return r
+}
+
+// corsMiddleware only allows the configured origins, "*" allows all
+func corsMiddleware(cfg *Config) gin.HandlerFunc {
+	for _, o := range cfg.CORSOrigins {
+		if o == "*" {
+			return cors.Default()
+		}
 	}
-	log.Printf("Listening on 0.0.0.0:%s\n", port)
-	log.Fatal(r.Run("0.0.0.0:" + port))
+	corsConfig := cors.DefaultConfig()
+	corsConfig.AllowOrigins = cfg.CORSOrigins
+	return cors.New(corsConfig)
 }
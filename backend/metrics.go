@@ -0,0 +1,118 @@
+package main
+
+import (
+	"math/big"
+	"sort"
+)
+
+// ElementMetrics is what /elements/:name/metrics reports about one element
+type ElementMetrics struct {
+	Name string `json:"name"`
+	Tier int    `json:"tier"`
+	// Recipes counts the recipes that follow the tier rule
+	Recipes int `json:"recipes"`
+	// RecipeTrees is the exact number of distinct recipe trees, in decimal
+	// since it does not fit a JSON number
+	RecipeTrees       string   `json:"recipeTrees"`
+	RecipeTreesDigits int      `json:"recipeTreesDigits"`
+	Packs             []string `json:"packs"`
+	Exclude           []string `json:"exclude,omitempty"`
+}
+
+// countRecipeTrees counts the distinct recipe trees of every element, the
+// trees kbest enumerates, without enumerating them: a base element has
+// one, a crafted element the sum over its recipes of the product of the
+// counts of both ingredients, and a recipe with twice the same ingredient
+// n(n+1)/2 since swapping its subtrees gives the same tree. Ingredients
+// are of a lower tier, so one pass in tier order is enough. Excluded
+// elements have no tree, and neither has what can only be made with them.
+// The caller holds mutex.
+func countRecipeTrees(exclude map[string]bool) map[string]*big.Int {
+	elements := make([]string, 0, len(recipesMap)+len(baseElements))
+	for e := range recipesMap {
+		if !baseElements[e] {
+			elements = append(elements, e)
+		}
+	}
+	sort.Slice(elements, func(i, j int) bool { return tierMap[elements[i]] < tierMap[elements[j]] })
+
+	counts := make(map[string]*big.Int, len(elements)+len(baseElements))
+	for e := range baseElements {
+		if exclude[e] {
+			counts[e] = big.NewInt(0)
+		} else {
+			counts[e] = big.NewInt(1)
+		}
+	}
+	count := func(e string) *big.Int {
+		if n, ok := counts[e]; ok {
+			return n
+		}
+		return new(big.Int)
+	}
+	for _, e := range elements {
+		total := new(big.Int)
+		if !exclude[e] {
+			for _, r := range validRecipes(e) {
+				a, b := count(r.a), count(r.b)
+				if r.a == r.b {
+					pairs := new(big.Int).Add(a, big.NewInt(1))
+					pairs.Mul(pairs, a).Rsh(pairs, 1)
+					total.Add(total, pairs)
+				} else {
+					total.Add(total, new(big.Int).Mul(a, b))
+				}
+			}
+		}
+		counts[e] = total
+	}
+	return counts
+}
+
+// parseExclude resolves the elements of an exclude list. The caller holds
+// mutex.
+func parseExclude(list []string) (map[string]bool, error) {
+	exclude := make(map[string]bool, len(list))
+	for _, name := range list {
+		e := resolveAlias(normalizeName(name))
+		if _, ok := recipesMap[e]; !ok && !baseElements[e] {
+			return nil, newAPIError(ErrUnknownElement, name)
+		}
+		exclude[e] = true
+	}
+	return exclude, nil
+}
+
+// elementMetrics returns the metrics of name under the active packs,
+// without the trees that use an element of exclude. Counts without
+// exclusions come from the graph cache.
+func elementMetrics(name string, exclude []string) (ElementMetrics, error) {
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	element := resolveAlias(normalizeName(name))
+	if _, ok := recipesMap[element]; !ok && !baseElements[element] {
+		return ElementMetrics{}, newAPIError(ErrUnknownElement, name)
+	}
+	excluded, err := parseExclude(exclude)
+	if err != nil {
+		return ElementMetrics{}, err
+	}
+
+	var counts map[string]*big.Int
+	if len(excluded) == 0 {
+		counts = cachedGraph().treeCounts
+	} else {
+		counts = countRecipeTrees(excluded)
+	}
+	trees := counts[element].String()
+	return ElementMetrics{
+		Name:              element,
+		Tier:              tierMap[element],
+		Recipes:           len(validRecipes(element)),
+		RecipeTrees:       trees,
+		RecipeTreesDigits: len(trees),
+		Packs:             append([]string{}, activePacks...),
+		Exclude:           sortedKeys(excluded),
+	}, nil
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Objectives of the optimize parameter of /find
+const (
+	OptimizeSteps    = "steps"    // fewest combinations
+	OptimizeDepth    = "depth"    // fewest combinations in a row
+	OptimizeElements = "elements" // fewest distinct elements, base included
+	OptimizeWeighted = "weighted" // lowest total weight of the elements made
+)
+
+var optimizeObjectives = []string{OptimizeSteps, OptimizeDepth, OptimizeElements, OptimizeWeighted}
+
+// optimalMethods are the methods of /find that take an objective
+var optimalMethods = []string{"optimal", "exact", "astar", "kbest"}
+
+// Objective is what the optimal solvers minimize. With OptimizeWeighted
+// making an element costs its weight: Weights of the request first, then
+// the weights of the overrides file, else 1 for crafted elements and 0 for
+// base elements.
+type Objective struct {
+	Name    string
+	Weights map[string]float64
+}
+
+// parseObjective validates the optimize and weights values of a request
+// for method. Weights without optimize select OptimizeWeighted.
+func parseObjective(method, optimize, weights string) (Objective, error) {
+	obj := Objective{Name: strings.ToLower(strings.TrimSpace(optimize))}
+	if weights != "" {
+		if obj.Name == "" {
+			obj.Name = OptimizeWeighted
+		}
+		if obj.Name != OptimizeWeighted {
+			return Objective{}, newAPIError(ErrInvalidObjective, "weights only apply to optimize=weighted")
+		}
+		obj.Weights = make(map[string]float64)
+		for _, entry := range splitList(weights) {
+			i := strings.LastIndex(entry, ":")
+			if i < 0 {
+				return Objective{}, newAPIError(ErrInvalidObjective, fmt.Sprintf("weight %q is not element:weight", entry))
+			}
+			w, err := strconv.ParseFloat(strings.TrimSpace(entry[i+1:]), 64)
+			if err != nil || w < 0 || math.IsInf(w, 0) || math.IsNaN(w) {
+				return Objective{}, newAPIError(ErrInvalidObjective, fmt.Sprintf("weight %q must be a number not below 0", entry))
+			}
+			obj.Weights[normalizeName(entry[:i])] = w
+		}
+	}
+	if obj.Name == "" {
+		return Objective{Name: OptimizeSteps}, nil
+	}
+	if !containsString(optimizeObjectives, obj.Name) {
+		return Objective{}, newAPIError(ErrInvalidObjective, optimize)
+	}
+	if !containsString(optimalMethods, method) {
+		return Objective{}, newAPIError(ErrInvalidObjective, "optimize only applies to methods "+strings.Join(optimalMethods, ", "))
+	}
+	return obj, nil
+}
+
+// resolve maps the aliases of the request weights to their elements and
+// rejects unknown elements. The caller holds mutex.
+func (o Objective) resolve() (Objective, error) {
+	if o.Name == "" {
+		o.Name = OptimizeSteps
+	}
+	if len(o.Weights) == 0 {
+		return o, nil
+	}
+	weights := make(map[string]float64, len(o.Weights))
+	for name, w := range o.Weights {
+		element := resolveAlias(name)
+		if _, ok := recipesMap[element]; !ok && !baseElements[element] {
+			return o, newAPIError(ErrInvalidObjective, "unknown element "+name+" in weights")
+		}
+		weights[element] = w
+	}
+	o.Weights = weights
+	return o, nil
+}
+
+// weight is the cost of making element under OptimizeWeighted. The caller
+// holds mutex.
+func (o Objective) weight(element string) float64 {
+	if w, ok := o.Weights[element]; ok {
+		return w
+	}
+	if w, ok := elementWeights[element]; ok {
+		return w
+	}
+	if baseElements[element] {
+		return 0
+	}
+	return 1
+}
+
+// baseCost is the cost of a base element in a recipe tree
+func (o Objective) baseCost(element string) float64 {
+	if o.Name == OptimizeWeighted {
+		return o.weight(element)
+	}
+	return 0
+}
+
+// combine is the cost of a recipe tree making element from two subtrees
+// costing a and b. It never decreases when a or b grows and is never below
+// either, which is what knuthMinCosts needs.
+func (o Objective) combine(element string, a, b float64) float64 {
+	switch o.Name {
+	case OptimizeDepth:
+		return 1 + max(a, b)
+	case OptimizeWeighted:
+		return o.weight(element) + a + b
+	}
+	return 1 + a + b
+}
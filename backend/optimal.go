@@ -0,0 +1,90 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"time"
+)
+
+// knuthResult is the outcome of knuthMinCosts: the minimal cost of every
+// element settled before the search stopped and the recipe achieving it
+type knuthResult struct {
+	cost    map[string]float64
+	chosen  map[string]solverRecipe
+	settled int
+}
+
+// knuthMinCosts runs Knuth's generalization of Dijkstra's algorithm over
+// the AND-OR recipe graph. A base element costs obj.baseCost, any other
+// element the cheapest of its recipes, and a recipe costs obj.combine of
+// its two ingredients: with OptimizeSteps one combination plus both
+// ingredients, the number of steps of the recipe tree. Elements are settled
+// in non-decreasing cost; a recipe is only evaluated once both ingredients
+// are settled, so a settled cost is final. It stops once target is settled,
+// "" settles every reachable element. OptimizeElements does not add up over
+// subtrees and is not supported here. It also stops when ctx is done. The
+// caller holds mutex.
+func knuthMinCosts(ctx context.Context, target string, obj Objective) *knuthResult {
+	res := &knuthResult{cost: make(map[string]float64), chosen: make(map[string]solverRecipe)}
+	uses := recipeUses()
+	best := make(map[string]float64)
+	q := &costQueue{}
+	for base := range baseElements {
+		best[base] = obj.baseCost(base)
+		heap.Push(q, costItem{cost: best[base], element: base})
+	}
+
+	for q.Len() > 0 && ctx.Err() == nil {
+		item := heap.Pop(q).(costItem)
+		if _, done := res.cost[item.element]; done {
+			continue
+		}
+		res.cost[item.element] = item.cost
+		if item.recipe != nil {
+			res.chosen[item.element] = *item.recipe
+		}
+		res.settled++
+		if item.element == target {
+			break
+		}
+
+		for _, r := range uses[item.element] {
+			if _, done := res.cost[r.element]; done {
+				continue
+			}
+			ca, okA := res.cost[r.a]
+			cb, okB := res.cost[r.b]
+			if !okA || !okB {
+				continue
+			}
+			cost := obj.combine(r.element, ca, cb)
+			if old, seen := best[r.element]; seen && old <= cost {
+				continue
+			}
+			best[r.element] = cost
+			r := r
+			heap.Push(q, costItem{cost: cost, element: r.element, recipe: &r})
+		}
+	}
+	return res
+}
+
+// optimalPath returns the recipe tree of target that minimizes obj, with
+// its cost and the number of elements settled. The fewest distinct
+// elements is a property of the whole tree rather than of its subtrees, so
+// OptimizeElements is answered by the exact search.
+func optimalPath(ctx context.Context, target string, obj Objective) (PathResult, bool, error) {
+	if obj.Name == OptimizeElements {
+		return exactPath(ctx, target, obj)
+	}
+	start := time.Now()
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	res := knuthMinCosts(ctx, target, obj)
+	cost, ok := res.cost[target]
+	if !ok {
+		return PathResult{Runtime: time.Since(start), NodesVisited: res.settled}, false, nil
+	}
+	return PathResult{Steps: treeSteps(target, res.chosen), Runtime: time.Since(start), NodesVisited: res.settled, Cost: &cost, Objective: obj.Name}, true, nil
+}
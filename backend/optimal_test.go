@@ -0,0 +1,235 @@
+package main
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// smallGraph is a hand-built recipe graph with several recipes per
+// element, a recipe with twice the same ingredient and one that breaks the
+// tier rule (stone from sand), which the solvers must ignore
+var smallGraph = []Recipe{
+	{Element: "steam", Ingredient1: "fire", Ingredient2: "water", Type: 1},
+	{Element: "mud", Ingredient1: "water", Ingredient2: "earth", Type: 1},
+	{Element: "lava", Ingredient1: "fire", Ingredient2: "earth", Type: 1},
+	{Element: "dust", Ingredient1: "earth", Ingredient2: "air", Type: 1},
+	{Element: "energy", Ingredient1: "fire", Ingredient2: "fire", Type: 1},
+	{Element: "stone", Ingredient1: "lava", Ingredient2: "air", Type: 2},
+	{Element: "stone", Ingredient1: "mud", Ingredient2: "fire", Type: 2},
+	{Element: "stone", Ingredient1: "sand", Ingredient2: "water", Type: 2},
+	{Element: "cloud", Ingredient1: "steam", Ingredient2: "air", Type: 2},
+	{Element: "cloud", Ingredient1: "water", Ingredient2: "air", Type: 2},
+	{Element: "sand", Ingredient1: "stone", Ingredient2: "air", Type: 3},
+	{Element: "sand", Ingredient1: "dust", Ingredient2: "dust", Type: 3},
+	{Element: "rain", Ingredient1: "cloud", Ingredient2: "water", Type: 3},
+	{Element: "rain", Ingredient1: "cloud", Ingredient2: "cloud", Type: 3},
+	{Element: "glass", Ingredient1: "sand", Ingredient2: "fire", Type: 4},
+	{Element: "glass", Ingredient1: "sand", Ingredient2: "energy", Type: 4},
+	{Element: "glass", Ingredient1: "sand", Ingredient2: "rain", Type: 4},
+	{Element: "window", Ingredient1: "glass", Ingredient2: "stone", Type: 5},
+	{Element: "window", Ingredient1: "glass", Ingredient2: "rain", Type: 5},
+	{Element: "window", Ingredient1: "sand", Ingredient2: "cloud", Type: 5},
+}
+
+// testObjectives are the tree objectives, weighted with weights that make
+// another tree the cheapest
+var testObjectives = []Objective{
+	{Name: OptimizeSteps},
+	{Name: OptimizeDepth},
+	{Name: OptimizeWeighted, Weights: map[string]float64{"dust": 4, "cloud": 0.5, "fire": 2, "water": 0.25}},
+}
+
+// useGraph replaces the recipe maps with recipes for the duration of the
+// test
+func useGraph(t *testing.T, recipes []Recipe) {
+	t.Helper()
+	mutex.Lock()
+	defer mutex.Unlock()
+	oldRecipes, oldTiers, oldRev, oldWeights := recipesMap, tierMap, revGraph, elementWeights
+	buildRecipeMap(recipes)
+	buildReverseGraph()
+	elementWeights = nil
+	t.Cleanup(func() {
+		mutex.Lock()
+		defer mutex.Unlock()
+		recipesMap, tierMap, revGraph, elementWeights = oldRecipes, oldTiers, oldRev, oldWeights
+		graphGeneration++
+	})
+}
+
+// craftedElements lists the elements of the graph that are not base
+// elements, sorted
+func craftedElements() []string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	var elements []string
+	for e := range recipesMap {
+		if !baseElements[e] {
+			elements = append(elements, e)
+		}
+	}
+	slices.Sort(elements)
+	return elements
+}
+
+// bruteTree is a recipe tree, left and right are nil for base elements
+type bruteTree struct {
+	element     string
+	left, right *bruteTree
+}
+
+func (b *bruteTree) cost(obj Objective) float64 {
+	if b.left == nil {
+		return obj.baseCost(b.element)
+	}
+	return obj.combine(b.element, b.left.cost(obj), b.right.cost(obj))
+}
+
+// distinctCost is what the tree pays with every element made once, the
+// value the exact search minimizes
+func (b *bruteTree) distinctCost(obj Objective) float64 {
+	seen := make(map[string]bool)
+	var walk func(b *bruteTree)
+	walk = func(b *bruteTree) {
+		seen[b.element] = true
+		if b.left != nil {
+			walk(b.left)
+			walk(b.right)
+		}
+	}
+	walk(b)
+	total := 0.0
+	for e := range seen {
+		switch {
+		case obj.Name == OptimizeWeighted:
+			total += obj.weight(e)
+		case obj.Name == OptimizeElements || !baseElements[e]:
+			total++
+		}
+	}
+	return total
+}
+
+// bruteTrees lists every recipe tree of every element by brute force.
+// Swapped subtrees of a recipe with twice the same ingredient are the same
+// tree and listed once.
+func bruteTrees() map[string][]*bruteTree {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	trees := make(map[string][]*bruteTree)
+	var list func(element string) []*bruteTree
+	list = func(element string) []*bruteTree {
+		if t, ok := trees[element]; ok {
+			return t
+		}
+		var out []*bruteTree
+		if baseElements[element] {
+			out = []*bruteTree{{element: element}}
+		}
+		for _, r := range validRecipes(element) {
+			for i, a := range list(r.a) {
+				for j, b := range list(r.b) {
+					if r.a == r.b && j < i {
+						continue
+					}
+					out = append(out, &bruteTree{element: element, left: a, right: b})
+				}
+			}
+		}
+		trees[element] = out
+		return out
+	}
+	for _, e := range craftedElements() {
+		list(e)
+	}
+	return trees
+}
+
+// bruteMin is the lowest of cost over trees
+func bruteMin(trees []*bruteTree, cost func(*bruteTree) float64) float64 {
+	best := cost(trees[0])
+	for _, b := range trees[1:] {
+		best = min(best, cost(b))
+	}
+	return best
+}
+
+func TestOptimalMatchesBruteForce(t *testing.T) {
+	useGraph(t, smallGraph)
+	trees := bruteTrees()
+	ctx := context.Background()
+	for _, obj := range testObjectives {
+		for _, e := range craftedElements() {
+			want := bruteMin(trees[e], func(b *bruteTree) float64 { return b.cost(obj) })
+
+			optimal, found, err := optimalPath(ctx, e, obj)
+			if err != nil || !found {
+				t.Fatalf("%s %s: optimal found=%v err=%v", obj.Name, e, found, err)
+			}
+			if *optimal.Cost != want {
+				t.Errorf("%s %s: optimal cost %v, brute force %v", obj.Name, e, *optimal.Cost, want)
+			}
+			if err := validatePath(e, optimal.Steps); err != nil {
+				t.Errorf("%s %s: optimal %v", obj.Name, e, err)
+			}
+
+			astar, found, err := astarPath(ctx, e, obj)
+			if err != nil || !found {
+				t.Fatalf("%s %s: astar found=%v err=%v", obj.Name, e, found, err)
+			}
+			if *astar.Cost != want {
+				t.Errorf("%s %s: astar cost %v, brute force %v", obj.Name, e, *astar.Cost, want)
+			}
+			if err := validatePath(e, astar.Steps); err != nil {
+				t.Errorf("%s %s: astar %v", obj.Name, e, err)
+			}
+
+			paths, _, _ := kBestPaths(ctx, e, 1, obj)
+			if len(paths) != 1 {
+				t.Fatalf("%s %s: kbest returned %d paths", obj.Name, e, len(paths))
+			}
+			if *paths[0].Cost != want {
+				t.Errorf("%s %s: kbest first cost %v, brute force %v", obj.Name, e, *paths[0].Cost, want)
+			}
+			if err := validatePath(e, paths[0].Steps); err != nil {
+				t.Errorf("%s %s: kbest %v", obj.Name, e, err)
+			}
+		}
+	}
+}
+
+func TestKBestListsEveryTree(t *testing.T) {
+	useGraph(t, smallGraph)
+	trees := bruteTrees()["window"]
+	obj := Objective{Name: OptimizeSteps}
+	var want []float64
+	for _, b := range trees {
+		want = append(want, b.cost(obj))
+	}
+	slices.Sort(want)
+
+	paths, _, _ := kBestPaths(context.Background(), "window", len(trees)+10, obj)
+	if len(paths) != len(trees) {
+		t.Fatalf("kbest returned %d trees, brute force has %d", len(paths), len(trees))
+	}
+	seen := make(map[string]bool)
+	for i, p := range paths {
+		if *p.Cost != want[i] {
+			t.Errorf("tree %d costs %v, want %v", i, *p.Cost, want[i])
+		}
+		key := strings.Join(p.Steps, "\n")
+		if seen[key] {
+			t.Errorf("tree %d is listed twice: %v", i, p.Steps)
+		}
+		seen[key] = true
+	}
+
+	mutex.RLock()
+	count := cachedGraph().treeCounts["window"]
+	mutex.RUnlock()
+	if count.Int64() != int64(len(trees)) {
+		t.Errorf("countRecipeTrees says %s, brute force has %d", count, len(trees))
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Overrides are hand-made fixes layered on top of the scraped recipes.
+// recipes.json always holds what the wiki says, the overrides are applied
+// every time it is loaded, in this order: rename, remove, add, tiers,
+// aliases, weights. Names are case-insensitive.
+type Overrides struct {
+	Rename  []RenamePatch     `json:"rename" yaml:"rename" toml:"rename"`
+	Remove  []RecipePatch     `json:"remove" yaml:"remove" toml:"remove"`
+	Add     []RecipePatch     `json:"add" yaml:"add" toml:"add"`
+	Tiers   map[string]int    `json:"tiers" yaml:"tiers" toml:"tiers"`
+	Aliases map[string]string `json:"aliases" yaml:"aliases" toml:"aliases"`
+	// Weights are the dataset weights of optimize=weighted, see Objective
+	Weights map[string]float64 `json:"weights" yaml:"weights" toml:"weights"`
+}
+
+// RenamePatch renames an element everywhere it appears, as result and as
+// ingredient. Renaming onto an existing element merges the two.
+type RenamePatch struct {
+	From string `json:"from" yaml:"from" toml:"from"`
+	To   string `json:"to" yaml:"to" toml:"to"`
+}
+
+// RecipePatch is one recipe to add or remove. A remove without
+// ingredients removes every recipe of the element. Tier and Pack only
+// matter when adding a new element; by default the tier is one more than
+// the highest ingredient tier and the pack comes from the config.
+type RecipePatch struct {
+	Element     string   `json:"element" yaml:"element" toml:"element"`
+	Ingredients []string `json:"ingredients" yaml:"ingredients" toml:"ingredients"`
+	Tier        *int     `json:"tier,omitempty" yaml:"tier" toml:"tier"`
+	Pack        string   `json:"pack,omitempty" yaml:"pack" toml:"pack"`
+}
+
+// Override patch outcomes
+const (
+	PatchApplied  = "applied"
+	PatchNoOp     = "noop"     // upstream already matches the patch
+	PatchConflict = "conflict" // the patch no longer fits upstream, it was skipped
+)
+
+// OverrideResult is the outcome of one patch
+type OverrideResult struct {
+	Patch  string `json:"patch"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// OverrideReport lists the outcome of every patch of the overrides file
+type OverrideReport struct {
+	File      string           `json:"file"`
+	Applied   int              `json:"applied"`
+	NoOp      int              `json:"noop"`
+	Conflicts int              `json:"conflicts"`
+	Results   []OverrideResult `json:"results"`
+}
+
+func (r *OverrideReport) add(patch, status, detail string) {
+	r.Results = append(r.Results, OverrideResult{Patch: patch, Status: status, Detail: detail})
+	switch status {
+	case PatchApplied:
+		r.Applied++
+	case PatchNoOp:
+		r.NoOp++
+	case PatchConflict:
+		r.Conflicts++
+	}
+}
+
+// loadOverrides reads a .yaml, .toml or .json overrides file. A missing
+// file means no overrides.
+func loadOverrides(path string) (*Overrides, error) {
+	o := &Overrides{}
+	if path == "" {
+		return o, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return o, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, o)
+	case ".toml":
+		err = toml.Unmarshal(data, o)
+	case ".json":
+		err = json.Unmarshal(data, o)
+	default:
+		return nil, fmt.Errorf("overrides %s: unsupported format, use .yaml, .toml or .json", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("overrides %s: %w", path, err)
+	}
+	return o, nil
+}
+
+// normalizeName is how element names are compared
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// sameIngredients compares two ingredient pairs in any order
+func sameIngredients(r Recipe, a, b string) bool {
+	return (r.Ingredient1 == a && r.Ingredient2 == b) || (r.Ingredient1 == b && r.Ingredient2 == a)
+}
+
+// recipeElements returns every element of recipes plus the base elements
+func recipeElements(recipes []Recipe) map[string]bool {
+	names := make(map[string]bool)
+	for e := range baseElements {
+		names[e] = true
+	}
+	for _, r := range recipes {
+		names[r.Element] = true
+		names[r.Ingredient1] = true
+		names[r.Ingredient2] = true
+	}
+	return names
+}
+
+// elementTiers returns the tier of every element made by a recipe
+func elementTiers(recipes []Recipe) map[string]int {
+	tiers := make(map[string]int)
+	for _, r := range recipes {
+		tiers[r.Element] = r.Type
+	}
+	return tiers
+}
+
+// patchedDataset is the dataset with the overrides applied
+type patchedDataset struct {
+	recipes []Recipe
+	aliases map[string]string
+	weights map[string]float64
+	report  *OverrideReport
+}
+
+// applyOverrides returns recipes with o applied, with the aliases and
+// weights it accepted and a report. recipes is not modified.
+func applyOverrides(recipes []Recipe, o *Overrides) *patchedDataset {
+	report := &OverrideReport{Results: []OverrideResult{}}
+	out := make([]Recipe, len(recipes))
+	for i, r := range recipes {
+		r.Element = normalizeName(r.Element)
+		r.Ingredient1 = normalizeName(r.Ingredient1)
+		r.Ingredient2 = normalizeName(r.Ingredient2)
+		out[i] = r
+	}
+
+	for _, p := range o.Rename {
+		from, to := normalizeName(p.From), normalizeName(p.To)
+		patch := fmt.Sprintf("rename %s -> %s", from, to)
+		names := recipeElements(out)
+		tiers := elementTiers(out)
+		fromTier, fromMade := tiers[from]
+		toTier, toMade := tiers[to]
+		switch {
+		case from == "" || to == "" || from == to:
+			report.add(patch, PatchConflict, "from and to must be two different names")
+		case !names[from] && names[to]:
+			report.add(patch, PatchNoOp, "already renamed upstream")
+		case !names[from]:
+			report.add(patch, PatchConflict, "unknown element "+from)
+		case fromMade && toMade && fromTier != toTier:
+			report.add(patch, PatchConflict, fmt.Sprintf("both exist upstream with tiers %d and %d", fromTier, toTier))
+		default:
+			for i := range out {
+				r := &out[i]
+				if r.Element == from {
+					r.Element = to
+					if toMade {
+						r.Type = toTier
+					}
+				}
+				if r.Ingredient1 == from {
+					r.Ingredient1 = to
+				}
+				if r.Ingredient2 == from {
+					r.Ingredient2 = to
+				}
+			}
+			report.add(patch, PatchApplied, "")
+		}
+	}
+
+	for _, p := range o.Remove {
+		element := normalizeName(p.Element)
+		patch := "remove " + element
+		if len(p.Ingredients) != 0 && len(p.Ingredients) != 2 {
+			report.add(patch, PatchConflict, "ingredients must be empty or two names")
+			continue
+		}
+		var a, b string
+		if len(p.Ingredients) == 2 {
+			a, b = normalizeName(p.Ingredients[0]), normalizeName(p.Ingredients[1])
+			patch = fmt.Sprintf("remove %s = %s + %s", element, a, b)
+		}
+		kept := out[:0]
+		removed := 0
+		for _, r := range out {
+			if r.Element == element && (a == "" || sameIngredients(r, a, b)) {
+				removed++
+				continue
+			}
+			kept = append(kept, r)
+		}
+		out = kept
+		if removed == 0 {
+			report.add(patch, PatchNoOp, "no such recipe upstream")
+		} else {
+			report.add(patch, PatchApplied, fmt.Sprintf("%d recipes removed", removed))
+		}
+	}
+
+	index := packIndex(appConfig.Packs)
+	for _, p := range o.Add {
+		element := normalizeName(p.Element)
+		patch := "add " + element
+		if element == "" || len(p.Ingredients) != 2 {
+			report.add(patch, PatchConflict, "an added recipe needs an element and two ingredients")
+			continue
+		}
+		a, b := normalizeName(p.Ingredients[0]), normalizeName(p.Ingredients[1])
+		patch = fmt.Sprintf("add %s = %s + %s", element, a, b)
+
+		exists := false
+		for _, r := range out {
+			if r.Element == element && sameIngredients(r, a, b) {
+				exists = true
+				break
+			}
+		}
+		if exists {
+			report.add(patch, PatchNoOp, "recipe exists upstream")
+			continue
+		}
+		names := recipeElements(out)
+		var unknown []string
+		for _, ingr := range []string{a, b} {
+			if !names[ingr] && !containsString(unknown, ingr) {
+				unknown = append(unknown, ingr)
+			}
+		}
+		if len(unknown) > 0 {
+			report.add(patch, PatchConflict, "unknown ingredients "+strings.Join(unknown, ", "))
+			continue
+		}
+
+		tiers := elementTiers(out)
+		tier, known := tiers[element]
+		if !known {
+			if p.Tier != nil {
+				tier = *p.Tier
+			} else {
+				tier = max(tiers[a], tiers[b]) + 1
+			}
+		}
+		pack := p.Pack
+		if pack == "" {
+			pack = recipePack(index, element, a, b)
+		}
+		out = append(out, Recipe{Element: element, Ingredient1: a, Ingredient2: b, Type: tier, Pack: pack})
+		report.add(patch, PatchApplied, "")
+	}
+
+	for _, element := range sortedKeys(o.Tiers) {
+		tier := o.Tiers[element]
+		element = normalizeName(element)
+		patch := fmt.Sprintf("tier %s = %d", element, tier)
+		changed, found := 0, false
+		for i := range out {
+			if out[i].Element != element {
+				continue
+			}
+			found = true
+			if out[i].Type != tier {
+				out[i].Type = tier
+				changed++
+			}
+		}
+		switch {
+		case !found:
+			report.add(patch, PatchConflict, "no recipe makes "+element)
+		case changed == 0:
+			report.add(patch, PatchNoOp, "tier matches upstream")
+		default:
+			report.add(patch, PatchApplied, "")
+		}
+	}
+
+	aliases := make(map[string]string)
+	names := recipeElements(out)
+	for _, alias := range sortedKeys(o.Aliases) {
+		target := normalizeName(o.Aliases[alias])
+		alias = normalizeName(alias)
+		patch := fmt.Sprintf("alias %s -> %s", alias, target)
+		switch {
+		case names[alias]:
+			report.add(patch, PatchConflict, alias+" is an element upstream")
+		case !names[target]:
+			report.add(patch, PatchConflict, "unknown element "+target)
+		default:
+			aliases[alias] = target
+			report.add(patch, PatchApplied, "")
+		}
+	}
+
+	weights := make(map[string]float64)
+	for _, element := range sortedKeys(o.Weights) {
+		weight := o.Weights[element]
+		element = normalizeName(element)
+		if target, ok := aliases[element]; ok {
+			element = target
+		}
+		patch := fmt.Sprintf("weight %s = %s", element, strconv.FormatFloat(weight, 'f', -1, 64))
+		switch {
+		case !names[element]:
+			report.add(patch, PatchConflict, "unknown element "+element)
+		case weight < 0 || math.IsInf(weight, 0) || math.IsNaN(weight):
+			report.add(patch, PatchConflict, "weights must be finite and not negative")
+		default:
+			weights[element] = weight
+			report.add(patch, PatchApplied, "")
+		}
+	}
+
+	return &patchedDataset{recipes: dedupeRecipes(out), aliases: aliases, weights: weights, report: report}
+}
+
+// dedupeRecipes drops repeated recipes, renames can produce them
+func dedupeRecipes(recipes []Recipe) []Recipe {
+	seen := make(map[[3]string]bool)
+	out := recipes[:0]
+	for _, r := range recipes {
+		a, b := r.Ingredient1, r.Ingredient2
+		if a > b {
+			a, b = b, a
+		}
+		key := [3]string{r.Element, a, b}
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		out = append(out, r)
+	}
+	return out
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// loadPatchedRecipes loads the dataset with the configured overrides
+// applied. A broken overrides file is reported and ignored.
+func loadPatchedRecipes(file string) (*patchedDataset, error) {
+	recipes, err := loadRecipesWithFallback(file)
+	if err != nil {
+		return nil, err
+	}
+	o, err := loadOverrides(appConfig.OverridesPath)
+	if err != nil {
+		fmt.Printf("[ERROR] Failed to load overrides: %v\n", err)
+		o = &Overrides{}
+	}
+	patched := applyOverrides(recipes, o)
+	report := patched.report
+	report.File = appConfig.OverridesPath
+	if len(report.Results) > 0 {
+		fmt.Printf("[DEBUG] Overrides: %d applied, %d no-op, %d conflicts\n", report.Applied, report.NoOp, report.Conflicts)
+	}
+	return patched, nil
+}
+
+// resolveAlias maps an alias from the overrides to its element, the
+// caller holds mutex
+func resolveAlias(name string) string {
+	if target, ok := elementAliases[name]; ok {
+		return target
+	}
+	return name
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// overrideBase is the upstream dataset the override tests patch
+var overrideBase = []Recipe{
+	{Element: "Steam", Ingredient1: "fire", Ingredient2: "water", Type: 1},
+	{Element: "mud", Ingredient1: "water", Ingredient2: "earth", Type: 1},
+	{Element: "lava", Ingredient1: "fire", Ingredient2: "earth", Type: 1},
+	{Element: "stone", Ingredient1: "lava", Ingredient2: "air", Type: 2},
+	{Element: "stone", Ingredient1: "mud", Ingredient2: "fire", Type: 2},
+	{Element: "rock", Ingredient1: "lava", Ingredient2: "air", Type: 2},
+	{Element: "rock", Ingredient1: "earth", Ingredient2: "earth", Type: 2},
+}
+
+// recipeList writes recipes of element as "a + b", sorted
+func recipeList(recipes []Recipe, element string) []string {
+	var out []string
+	for _, r := range recipes {
+		if r.Element == element {
+			out = append(out, r.Ingredient1+" + "+r.Ingredient2)
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+func tierOf(recipes []Recipe, element string) int {
+	for _, r := range recipes {
+		if r.Element == element {
+			return r.Type
+		}
+	}
+	return -1
+}
+
+func TestApplyOverrides(t *testing.T) {
+	tier := 7
+	tests := []struct {
+		name      string
+		overrides Overrides
+		want      []OverrideResult
+		check     func(t *testing.T, p *patchedDataset)
+	}{
+		{
+			name: "rename is applied before remove",
+			overrides: Overrides{
+				Rename: []RenamePatch{{From: "steam", To: "Vapor"}},
+				Remove: []RecipePatch{{Element: "vapor"}},
+			},
+			want: []OverrideResult{
+				{Patch: "rename steam -> vapor", Status: PatchApplied},
+				{Patch: "remove vapor", Status: PatchApplied},
+			},
+			check: func(t *testing.T, p *patchedDataset) {
+				if got := recipeList(p.recipes, "steam"); got != nil {
+					t.Errorf("steam recipes left: %v", got)
+				}
+				if got := recipeList(p.recipes, "vapor"); got != nil {
+					t.Errorf("vapor recipes left: %v", got)
+				}
+			},
+		},
+		{
+			name: "rename onto an existing element merges the recipes",
+			overrides: Overrides{
+				Rename: []RenamePatch{{From: "stone", To: "rock"}},
+			},
+			want: []OverrideResult{
+				{Patch: "rename stone -> rock", Status: PatchApplied},
+			},
+			check: func(t *testing.T, p *patchedDataset) {
+				want := []string{"earth + earth", "lava + air", "mud + fire"}
+				if got := recipeList(p.recipes, "rock"); !slices.Equal(got, want) {
+					t.Errorf("rock recipes %v, want %v", got, want)
+				}
+				if got := recipeList(p.recipes, "stone"); got != nil {
+					t.Errorf("stone recipes left: %v", got)
+				}
+			},
+		},
+		{
+			name: "renames that cannot apply",
+			overrides: Overrides{
+				Rename: []RenamePatch{
+					{From: "ghost", To: "steam"},
+					{From: "ghost", To: "spirit"},
+					{From: "steam", To: "stone"},
+					{From: "mud", To: " MUD "},
+				},
+			},
+			want: []OverrideResult{
+				{Patch: "rename ghost -> steam", Status: PatchNoOp},
+				{Patch: "rename ghost -> spirit", Status: PatchConflict},
+				{Patch: "rename steam -> stone", Status: PatchConflict},
+				{Patch: "rename mud -> mud", Status: PatchConflict},
+			},
+			check: func(t *testing.T, p *patchedDataset) {
+				if len(p.recipes) != len(overrideBase) {
+					t.Errorf("%d recipes, want the %d upstream ones", len(p.recipes), len(overrideBase))
+				}
+			},
+		},
+		{
+			name: "remove one recipe or every recipe of an element",
+			overrides: Overrides{
+				Remove: []RecipePatch{
+					{Element: "stone", Ingredients: []string{"fire", "mud"}},
+					{Element: "rock"},
+					{Element: "stone", Ingredients: []string{"water", "air"}},
+					{Element: "ghost"},
+					{Element: "mud", Ingredients: []string{"water"}},
+				},
+			},
+			want: []OverrideResult{
+				{Patch: "remove stone = fire + mud", Status: PatchApplied},
+				{Patch: "remove rock", Status: PatchApplied},
+				{Patch: "remove stone = water + air", Status: PatchNoOp},
+				{Patch: "remove ghost", Status: PatchNoOp},
+				{Patch: "remove mud", Status: PatchConflict},
+			},
+			check: func(t *testing.T, p *patchedDataset) {
+				if got := recipeList(p.recipes, "stone"); !slices.Equal(got, []string{"lava + air"}) {
+					t.Errorf("stone recipes %v, want only lava + air", got)
+				}
+				if got := recipeList(p.recipes, "rock"); got != nil {
+					t.Errorf("rock recipes left: %v", got)
+				}
+				if got := recipeList(p.recipes, "mud"); len(got) != 1 {
+					t.Errorf("mud recipes %v, want the upstream one", got)
+				}
+			},
+		},
+		{
+			name: "add a recipe",
+			overrides: Overrides{
+				Add: []RecipePatch{
+					{Element: "geyser", Ingredients: []string{"steam", "stone"}},
+					{Element: "obsidian", Ingredients: []string{"lava", "water"}, Tier: &tier},
+					{Element: "mud", Ingredients: []string{"earth", "water"}},
+					{Element: "brick", Ingredients: []string{"clay", "fire"}},
+					{Element: "brick", Ingredients: []string{"fire"}},
+				},
+			},
+			want: []OverrideResult{
+				{Patch: "add geyser = steam + stone", Status: PatchApplied},
+				{Patch: "add obsidian = lava + water", Status: PatchApplied},
+				{Patch: "add mud = earth + water", Status: PatchNoOp},
+				{Patch: "add brick = clay + fire", Status: PatchConflict},
+				{Patch: "add brick", Status: PatchConflict},
+			},
+			check: func(t *testing.T, p *patchedDataset) {
+				if got := tierOf(p.recipes, "geyser"); got != 3 {
+					t.Errorf("geyser tier %d, want one above its ingredients", got)
+				}
+				if got := tierOf(p.recipes, "obsidian"); got != tier {
+					t.Errorf("obsidian tier %d, want %d", got, tier)
+				}
+				if got := recipeList(p.recipes, "brick"); got != nil {
+					t.Errorf("brick recipes added: %v", got)
+				}
+			},
+		},
+		{
+			name: "tier patches",
+			overrides: Overrides{
+				Tiers: map[string]int{"stone": 3, "mud": 1, "ghost": 2},
+			},
+			want: []OverrideResult{
+				{Patch: "tier ghost = 2", Status: PatchConflict},
+				{Patch: "tier mud = 1", Status: PatchNoOp},
+				{Patch: "tier stone = 3", Status: PatchApplied},
+			},
+			check: func(t *testing.T, p *patchedDataset) {
+				for _, r := range p.recipes {
+					if r.Element == "stone" && r.Type != 3 {
+						t.Errorf("stone = %s + %s has tier %d, want 3", r.Ingredient1, r.Ingredient2, r.Type)
+					}
+				}
+			},
+		},
+		{
+			name: "alias patches",
+			overrides: Overrides{
+				Aliases: map[string]string{"Boulder": "rock", "mud": "stone", "spook": "ghost"},
+			},
+			want: []OverrideResult{
+				{Patch: "alias boulder -> rock", Status: PatchApplied},
+				{Patch: "alias mud -> stone", Status: PatchConflict},
+				{Patch: "alias spook -> ghost", Status: PatchConflict},
+			},
+			check: func(t *testing.T, p *patchedDataset) {
+				want := map[string]string{"boulder": "rock"}
+				if len(p.aliases) != len(want) || p.aliases["boulder"] != "rock" {
+					t.Errorf("aliases %v, want %v", p.aliases, want)
+				}
+			},
+		},
+		{
+			name: "weight patches follow the aliases",
+			overrides: Overrides{
+				Aliases: map[string]string{"boulder": "rock"},
+				Weights: map[string]float64{"boulder": 2.5, "fire": -1, "ghost": 1, "water": 0},
+			},
+			want: []OverrideResult{
+				{Patch: "alias boulder -> rock", Status: PatchApplied},
+				{Patch: "weight rock = 2.5", Status: PatchApplied},
+				{Patch: "weight fire = -1", Status: PatchConflict},
+				{Patch: "weight ghost = 1", Status: PatchConflict},
+				{Patch: "weight water = 0", Status: PatchApplied},
+			},
+			check: func(t *testing.T, p *patchedDataset) {
+				if len(p.weights) != 2 || p.weights["rock"] != 2.5 || p.weights["water"] != 0 {
+					t.Errorf("weights %v, want rock 2.5 and water 0", p.weights)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := slices.Clone(overrideBase)
+			p := applyOverrides(upstream, &tt.overrides)
+			if !slices.Equal(upstream, overrideBase) {
+				t.Error("applyOverrides modified its input")
+			}
+
+			report := p.report
+			if len(report.Results) != len(tt.want) {
+				t.Fatalf("report has %d results, want %d: %+v", len(report.Results), len(tt.want), report.Results)
+			}
+			applied, noop, conflicts := 0, 0, 0
+			for i, want := range tt.want {
+				got := report.Results[i]
+				if got.Patch != want.Patch || got.Status != want.Status {
+					t.Errorf("result %d is %q %s (%s), want %q %s", i, got.Patch, got.Status, got.Detail, want.Patch, want.Status)
+				}
+				switch want.Status {
+				case PatchApplied:
+					applied++
+				case PatchNoOp:
+					noop++
+				case PatchConflict:
+					conflicts++
+				}
+			}
+			if report.Applied != applied || report.NoOp != noop || report.Conflicts != conflicts {
+				t.Errorf("report counts %d applied, %d noop, %d conflicts, want %d, %d, %d",
+					report.Applied, report.NoOp, report.Conflicts, applied, noop, conflicts)
+			}
+			tt.check(t, p)
+		})
+	}
+}
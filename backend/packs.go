@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// BasePack is the pack of every recipe that uses no expansion content.
+// It is always enabled.
+const BasePack = "base"
+
+// Pack is a content pack: recipes that make or use one of its elements
+// belong to the pack
+type Pack struct {
+	Name        string   `json:"name" yaml:"name" toml:"name"`
+	Description string   `json:"description" yaml:"description" toml:"description"`
+	Elements    []string `json:"elements" yaml:"elements" toml:"elements"`
+}
+
+// defaultPacks are the expansions of Little Alchemy 2 that used to be
+// dropped by the scraper
+func defaultPacks() []Pack {
+	return []Pack{
+		{
+			Name:        "myths",
+			Description: "Myths and Monsters",
+			Elements: []string{
+				"zeus", "angel", "jiangshi", "monster", "baba yaga", "book of the dead", "cockatrice",
+				"curse", "deity", "demon", "heaven", "holy grail", "holy water", "necromancer",
+				"paladin", "selkie", "troll", "babe the blue ox", "cosmic egg", "cupid", "cyclops",
+				"dionysus", "faerie", "paul bunyan", "elf", "maui's fishhook",
+			},
+		},
+		{
+			Name:        "time",
+			Description: "Time and the elements unlocked by it",
+			Elements:    []string{"time", "ruins", "archeologist"},
+		},
+	}
+}
+
+// packIndex maps every pack element to its pack
+func packIndex(packs []Pack) map[string]string {
+	index := make(map[string]string)
+	for _, p := range packs {
+		for _, e := range p.Elements {
+			index[strings.ToLower(strings.TrimSpace(e))] = p.Name
+		}
+	}
+	return index
+}
+
+// recipePack returns the pack of a recipe: the pack of its result, else the
+// pack of its first ingredient that belongs to one, else BasePack
+func recipePack(index map[string]string, element, ingredient1, ingredient2 string) string {
+	for _, name := range []string{element, ingredient1, ingredient2} {
+		if pack, ok := index[name]; ok {
+			return pack
+		}
+	}
+	return BasePack
+}
+
+// tagRecipePacks sets the Pack of every scraped recipe
+func tagRecipePacks(recipes []RecipeType, packs []Pack) {
+	index := packIndex(packs)
+	for i := range recipes {
+		r := &recipes[i]
+		r.Pack = recipePack(index, r.Element, r.Ingredient1, r.Ingredient2)
+	}
+}
+
+// parsePacks validates a comma-separated pack list against the configured
+// packs. BasePack is always part of the result.
+func parsePacks(list string) ([]string, error) {
+	known := map[string]bool{BasePack: true}
+	for _, p := range appConfig.Packs {
+		known[p.Name] = true
+	}
+	packs := []string{BasePack}
+	for _, name := range splitList(strings.ToLower(list)) {
+		if !known[name] {
+			return nil, newAPIError(ErrInvalidPack, name)
+		}
+		if !containsString(packs, name) {
+			packs = append(packs, name)
+		}
+	}
+	sort.Strings(packs)
+	return packs, nil
+}
+
+// filterRecipesByPack keeps the recipes of the enabled packs, recipes
+// without a pack are base recipes
+func filterRecipesByPack(recipes []Recipe, packs []string) []Recipe {
+	out := make([]Recipe, 0, len(recipes))
+	for _, r := range recipes {
+		pack := r.Pack
+		if pack == "" {
+			pack = BasePack
+		}
+		if containsString(packs, pack) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// packGate serializes searches by pack selection. The recipe maps hold the
+// recipes of one pack selection at a time: searches with the same selection
+// run together, a different selection waits until they are done and then
+// rebuilds the maps.
+var packGate = struct {
+	sync.Mutex
+	cond  *sync.Cond
+	key   string
+	users int
+}{}
+
+func init() {
+	packGate.cond = sync.NewCond(&packGate.Mutex)
+}
+
+// acquirePacks makes packs the active selection and must be paired with
+// releasePacks. nil selects the configured default packs.
+func acquirePacks(packs []string) {
+	if packs == nil {
+		packs, _ = parsePacks(strings.Join(appConfig.DefaultPacks, ","))
+	}
+	key := strings.Join(packs, ",")
+
+	packGate.Lock()
+	defer packGate.Unlock()
+	for packGate.users > 0 && packGate.key != key {
+		packGate.cond.Wait()
+	}
+	if packGate.key != key {
+		mutex.Lock()
+		activePacks = packs
+		buildRecipeMap(filterRecipesByPack(loadedRecipes, activePacks))
+		buildReverseGraph()
+		mutex.Unlock()
+		packGate.key = key
+		fmt.Printf("[DEBUG] Active packs: %s\n", key)
+	}
+	packGate.users++
+}
+
+func releasePacks() {
+	packGate.Lock()
+	defer packGate.Unlock()
+	packGate.users--
+	if packGate.users == 0 {
+		packGate.cond.Broadcast()
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// DefaultSource is the parser whose dataset the server loads
+const DefaultSource = "la2"
+
+// RecipeParser turns the fetched elements page of one game into recipes.
+// Parsers are registered by name and picked with /admin/scrape?source=.
+type RecipeParser interface {
+	// Name is the source name of ?source= and of the dataset file
+	Name() string
+	Title() string
+	// URL is the elements page to fetch
+	URL() string
+	// Parse returns the recipes with their tier (Type) and Pack set. The
+	// report maps page sections to tiers and lists the element page links
+	// for the deep crawl; the error is ErrScrapeLayout when the page does
+	// not look as expected.
+	Parse(r io.Reader) ([]RecipeType, *LayoutReport, error)
+}
+
+var recipeParsers = make(map[string]RecipeParser)
+
+func registerParser(p RecipeParser) {
+	if _, dup := recipeParsers[p.Name()]; dup {
+		panic("recipe parser registered twice: " + p.Name())
+	}
+	recipeParsers[p.Name()] = p
+}
+
+func init() {
+	registerParser(la2Parser{})
+	registerParser(&tableListParser{
+		name:  "la1",
+		title: "Little Alchemy 1",
+		url:   "https://little-alchemy.fandom.com/wiki/Elements_(Little_Alchemy)",
+		base:  []string{"air", "earth", "fire", "water"},
+	})
+}
+
+// lookupParser returns the parser of source, "" is DefaultSource
+func lookupParser(source string) (RecipeParser, error) {
+	if source == "" {
+		source = DefaultSource
+	}
+	p, ok := recipeParsers[strings.ToLower(source)]
+	if !ok {
+		return nil, newAPIError(ErrInvalidSource, source)
+	}
+	return p, nil
+}
+
+// parserNames returns the registered source names, sorted
+func parserNames() []string {
+	return sortedKeys(recipeParsers)
+}
+
+// sourceDataPath is the dataset written by a source: the configured
+// dataPath for DefaultSource, recipes.<source>.json next to it otherwise
+func sourceDataPath(source string) string {
+	if source == DefaultSource {
+		return appConfig.DataPath
+	}
+	return filepath.Join(filepath.Dir(appConfig.DataPath), "recipes."+source+".json")
+}
+
+// sourceElementsPath is where the deep crawl of a source is written
+func sourceElementsPath(source string) string {
+	if source == DefaultSource {
+		return appConfig.ElementsPath
+	}
+	return filepath.Join(filepath.Dir(appConfig.ElementsPath), "elements."+source+".json")
+}
+
+// la2Parser reads the Little Alchemy 2 fandom page, tiers come from the
+// section headings (see parseElementsDocument)
+type la2Parser struct{}
+
+func (la2Parser) Name() string  { return "la2" }
+func (la2Parser) Title() string { return "Little Alchemy 2" }
+func (la2Parser) URL() string   { return appConfig.ScrapeURL }
+
+func (la2Parser) Parse(r io.Reader) ([]RecipeType, *LayoutReport, error) {
+	return parseElementsPage(r)
+}
+
+// tableListParser reads pages that list every element in wiki tables like
+// the Little Alchemy 2 page, but without tier sections. Headings are only
+// reported; tiers are the number of combinations needed from the base
+// elements. Recipes have no pack.
+type tableListParser struct {
+	name, title, url string
+	base             []string
+}
+
+func (p *tableListParser) Name() string  { return p.name }
+func (p *tableListParser) Title() string { return p.title }
+func (p *tableListParser) URL() string   { return p.url }
+
+func (p *tableListParser) Parse(r io.Reader) ([]RecipeType, *LayoutReport, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	report := &LayoutReport{Sections: []LayoutSection{}, Errors: []string{}, Warnings: []string{}, Links: make(map[string]string)}
+	var recipes []RecipeType
+	var current *LayoutSection
+	tables := 0
+	doc.Find("h2, h3, table.list-table, table.wikitable").Each(func(_ int, s *goquery.Selection) {
+		if !s.Is("table") {
+			report.Sections = append(report.Sections, LayoutSection{Heading: headingText(s), Tier: -1, Inferred: true})
+			current = &report.Sections[len(report.Sections)-1]
+			return
+		}
+		if current == nil {
+			report.Sections = append(report.Sections, LayoutSection{Tier: -1, Inferred: true})
+			current = &report.Sections[len(report.Sections)-1]
+		}
+		tables++
+		current.Tables++
+		collectElementLinks(s, report.Links)
+		parsed := parseElementsTable(s, -1)
+		current.Recipes += len(parsed)
+		recipes = append(recipes, parsed...)
+	})
+	// Headings without tables are page furniture here, not layout problems
+	kept := report.Sections[:0]
+	for _, section := range report.Sections {
+		if section.Tables > 0 {
+			kept = append(kept, section)
+		}
+	}
+	report.Sections = kept
+
+	switch {
+	case tables == 0:
+		report.Errors = append(report.Errors, "no element tables")
+	case len(recipes) == 0:
+		report.Errors = append(report.Errors, "no recipes in the element tables")
+	}
+	for _, name := range p.base {
+		if _, ok := report.Links[name]; !ok && tables > 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("base element %q is not listed", name))
+		}
+	}
+	report.Warnings = append(report.Warnings, tiersFromBase(recipes, p.base)...)
+	tagRecipePacks(recipes, nil)
+
+	if !report.OK() {
+		return recipes, report, newAPIError(ErrScrapeLayout, strings.Join(report.Errors, "; "))
+	}
+	return recipes, report, nil
+}
+
+// tiersFromBase sets the tier of every recipe to the lowest number of
+// combination steps that makes its element from base: one above the
+// highest ingredient, minimised over the recipes of the element. Elements
+// that cannot be made from base get the highest tier plus one and are
+// returned as warnings.
+func tiersFromBase(recipes []RecipeType, base []string) []string {
+	tiers := make(map[string]int)
+	for _, name := range base {
+		tiers[name] = 0
+	}
+	for changed := true; changed; {
+		changed = false
+		for _, r := range recipes {
+			t1, ok1 := tiers[r.Ingredient1]
+			t2, ok2 := tiers[r.Ingredient2]
+			if !ok1 || !ok2 {
+				continue
+			}
+			t := max(t1, t2) + 1
+			if old, ok := tiers[r.Element]; !ok || t < old {
+				tiers[r.Element] = t
+				changed = true
+			}
+		}
+	}
+
+	maxTier := 0
+	for _, t := range tiers {
+		maxTier = max(maxTier, t)
+	}
+	var unreachable []string
+	for i := range recipes {
+		t, ok := tiers[recipes[i].Element]
+		if !ok {
+			t = maxTier + 1
+			if !containsString(unreachable, recipes[i].Element) {
+				unreachable = append(unreachable, recipes[i].Element)
+			}
+		}
+		recipes[i].Type = t
+	}
+	sort.Strings(unreachable)
+	var warnings []string
+	for _, name := range unreachable {
+		warnings = append(warnings, fmt.Sprintf("%s cannot be made from the base elements, put in tier %d", name, maxTier+1))
+	}
+	return warnings
+}
@@ -0,0 +1,834 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: recipe.proto
+
+package recipepb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FindRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Target string                 `protobuf:"bytes,1,opt,name=target,proto3" json:"target,omitempty"`
+	// "bfs", "dfs", "iddfs", "optimal", "exact", "astar" or "kbest", same as
+	// the method query parameter of /find.
+	Method string `protobuf:"bytes,2,opt,name=method,proto3" json:"method,omitempty"`
+	// Number of recipes wanted, 1 when unset.
+	Count int32 `protobuf:"varint,3,opt,name=count,proto3" json:"count,omitempty"`
+	// Only used when count is 1.
+	Bidirectional bool `protobuf:"varint,4,opt,name=bidirectional,proto3" json:"bidirectional,omitempty"`
+	// What the optimal, exact, astar and kbest methods minimize, same values
+	// as the optimize query parameter of /find. "steps" when unset.
+	Optimize string `protobuf:"bytes,5,opt,name=optimize,proto3" json:"optimize,omitempty"`
+	// element:weight list of optimize "weighted", as the weights query
+	// parameter of /find.
+	Weights string `protobuf:"bytes,6,opt,name=weights,proto3" json:"weights,omitempty"`
+	// Content packs whose recipes may be used, the configured default packs
+	// when empty.
+	Packs []string `protobuf:"bytes,7,rep,name=packs,proto3" json:"packs,omitempty"`
+	// Deepest limit of method iddfs, the configured maxDepth when unset.
+	MaxDepth      int32 `protobuf:"varint,8,opt,name=max_depth,json=maxDepth,proto3" json:"max_depth,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindRequest) Reset() {
+	*x = FindRequest{}
+	mi := &file_recipe_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindRequest) ProtoMessage() {}
+
+func (x *FindRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindRequest.ProtoReflect.Descriptor instead.
+func (*FindRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FindRequest) GetTarget() string {
+	if x != nil {
+		return x.Target
+	}
+	return ""
+}
+
+func (x *FindRequest) GetMethod() string {
+	if x != nil {
+		return x.Method
+	}
+	return ""
+}
+
+func (x *FindRequest) GetCount() int32 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+func (x *FindRequest) GetBidirectional() bool {
+	if x != nil {
+		return x.Bidirectional
+	}
+	return false
+}
+
+func (x *FindRequest) GetOptimize() string {
+	if x != nil {
+		return x.Optimize
+	}
+	return ""
+}
+
+func (x *FindRequest) GetWeights() string {
+	if x != nil {
+		return x.Weights
+	}
+	return ""
+}
+
+func (x *FindRequest) GetPacks() []string {
+	if x != nil {
+		return x.Packs
+	}
+	return nil
+}
+
+func (x *FindRequest) GetMaxDepth() int32 {
+	if x != nil {
+		return x.MaxDepth
+	}
+	return 0
+}
+
+// RecipeStep is one combination, ingredient1 + ingredient2 = result.
+type RecipeStep struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ingredient1   string                 `protobuf:"bytes,1,opt,name=ingredient1,proto3" json:"ingredient1,omitempty"`
+	Ingredient2   string                 `protobuf:"bytes,2,opt,name=ingredient2,proto3" json:"ingredient2,omitempty"`
+	Result        string                 `protobuf:"bytes,3,opt,name=result,proto3" json:"result,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecipeStep) Reset() {
+	*x = RecipeStep{}
+	mi := &file_recipe_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecipeStep) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecipeStep) ProtoMessage() {}
+
+func (x *RecipeStep) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecipeStep.ProtoReflect.Descriptor instead.
+func (*RecipeStep) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *RecipeStep) GetIngredient1() string {
+	if x != nil {
+		return x.Ingredient1
+	}
+	return ""
+}
+
+func (x *RecipeStep) GetIngredient2() string {
+	if x != nil {
+		return x.Ingredient2
+	}
+	return ""
+}
+
+func (x *RecipeStep) GetResult() string {
+	if x != nil {
+		return x.Result
+	}
+	return ""
+}
+
+type RecipePath struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 1-based position of the path in the search result.
+	Index        int32                `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Steps        []*RecipeStep        `protobuf:"bytes,2,rep,name=steps,proto3" json:"steps,omitempty"`
+	Runtime      *durationpb.Duration `protobuf:"bytes,3,opt,name=runtime,proto3" json:"runtime,omitempty"`
+	NodesVisited int32                `protobuf:"varint,4,opt,name=nodes_visited,json=nodesVisited,proto3" json:"nodes_visited,omitempty"`
+	// Objective value of the path, only set by the methods that optimize.
+	Cost *float64 `protobuf:"fixed64,5,opt,name=cost,proto3,oneof" json:"cost,omitempty"`
+	// How far cost may be above the optimum, 0 when it is proven optimal.
+	// Only set by the exact search.
+	Gap *float64 `protobuf:"fixed64,6,opt,name=gap,proto3,oneof" json:"gap,omitempty"`
+	// The objective cost is measured in.
+	Objective string `protobuf:"bytes,7,opt,name=objective,proto3" json:"objective,omitempty"`
+	// Nodes visited by every iteration of method iddfs.
+	NodesByDepth  []*DepthNodes `protobuf:"bytes,8,rep,name=nodes_by_depth,json=nodesByDepth,proto3" json:"nodes_by_depth,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RecipePath) Reset() {
+	*x = RecipePath{}
+	mi := &file_recipe_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecipePath) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecipePath) ProtoMessage() {}
+
+func (x *RecipePath) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecipePath.ProtoReflect.Descriptor instead.
+func (*RecipePath) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *RecipePath) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *RecipePath) GetSteps() []*RecipeStep {
+	if x != nil {
+		return x.Steps
+	}
+	return nil
+}
+
+func (x *RecipePath) GetRuntime() *durationpb.Duration {
+	if x != nil {
+		return x.Runtime
+	}
+	return nil
+}
+
+func (x *RecipePath) GetNodesVisited() int32 {
+	if x != nil {
+		return x.NodesVisited
+	}
+	return 0
+}
+
+func (x *RecipePath) GetCost() float64 {
+	if x != nil && x.Cost != nil {
+		return *x.Cost
+	}
+	return 0
+}
+
+func (x *RecipePath) GetGap() float64 {
+	if x != nil && x.Gap != nil {
+		return *x.Gap
+	}
+	return 0
+}
+
+func (x *RecipePath) GetObjective() string {
+	if x != nil {
+		return x.Objective
+	}
+	return ""
+}
+
+func (x *RecipePath) GetNodesByDepth() []*DepthNodes {
+	if x != nil {
+		return x.NodesByDepth
+	}
+	return nil
+}
+
+// DepthNodes is one iteration of iddfs: the depth limit and the nodes it visited.
+type DepthNodes struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Depth         int32                  `protobuf:"varint,1,opt,name=depth,proto3" json:"depth,omitempty"`
+	Nodes         int32                  `protobuf:"varint,2,opt,name=nodes,proto3" json:"nodes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DepthNodes) Reset() {
+	*x = DepthNodes{}
+	mi := &file_recipe_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DepthNodes) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DepthNodes) ProtoMessage() {}
+
+func (x *DepthNodes) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DepthNodes.ProtoReflect.Descriptor instead.
+func (*DepthNodes) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DepthNodes) GetDepth() int32 {
+	if x != nil {
+		return x.Depth
+	}
+	return 0
+}
+
+func (x *DepthNodes) GetNodes() int32 {
+	if x != nil {
+		return x.Nodes
+	}
+	return 0
+}
+
+type FindResponse struct {
+	state        protoimpl.MessageState `protogen:"open.v1"`
+	Found        bool                   `protobuf:"varint,1,opt,name=found,proto3" json:"found,omitempty"`
+	Paths        []*RecipePath          `protobuf:"bytes,2,rep,name=paths,proto3" json:"paths,omitempty"`
+	Runtime      *durationpb.Duration   `protobuf:"bytes,3,opt,name=runtime,proto3" json:"runtime,omitempty"`
+	NodesVisited int32                  `protobuf:"varint,4,opt,name=nodes_visited,json=nodesVisited,proto3" json:"nodes_visited,omitempty"`
+	// Set by method iddfs, also when nothing was found.
+	NodesByDepth  []*DepthNodes `protobuf:"bytes,5,rep,name=nodes_by_depth,json=nodesByDepth,proto3" json:"nodes_by_depth,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FindResponse) Reset() {
+	*x = FindResponse{}
+	mi := &file_recipe_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FindResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FindResponse) ProtoMessage() {}
+
+func (x *FindResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FindResponse.ProtoReflect.Descriptor instead.
+func (*FindResponse) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *FindResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *FindResponse) GetPaths() []*RecipePath {
+	if x != nil {
+		return x.Paths
+	}
+	return nil
+}
+
+func (x *FindResponse) GetRuntime() *durationpb.Duration {
+	if x != nil {
+		return x.Runtime
+	}
+	return nil
+}
+
+func (x *FindResponse) GetNodesVisited() int32 {
+	if x != nil {
+		return x.NodesVisited
+	}
+	return 0
+}
+
+func (x *FindResponse) GetNodesByDepth() []*DepthNodes {
+	if x != nil {
+		return x.NodesByDepth
+	}
+	return nil
+}
+
+type Ingredients struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ingredient1   string                 `protobuf:"bytes,1,opt,name=ingredient1,proto3" json:"ingredient1,omitempty"`
+	Ingredient2   string                 `protobuf:"bytes,2,opt,name=ingredient2,proto3" json:"ingredient2,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Ingredients) Reset() {
+	*x = Ingredients{}
+	mi := &file_recipe_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Ingredients) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Ingredients) ProtoMessage() {}
+
+func (x *Ingredients) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Ingredients.ProtoReflect.Descriptor instead.
+func (*Ingredients) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *Ingredients) GetIngredient1() string {
+	if x != nil {
+		return x.Ingredient1
+	}
+	return ""
+}
+
+func (x *Ingredients) GetIngredient2() string {
+	if x != nil {
+		return x.Ingredient2
+	}
+	return ""
+}
+
+type Element struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Tier          int32                  `protobuf:"varint,2,opt,name=tier,proto3" json:"tier,omitempty"`
+	Recipes       []*Ingredients         `protobuf:"bytes,3,rep,name=recipes,proto3" json:"recipes,omitempty"`
+	UsedIn        []string               `protobuf:"bytes,4,rep,name=used_in,json=usedIn,proto3" json:"used_in,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Element) Reset() {
+	*x = Element{}
+	mi := &file_recipe_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Element) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Element) ProtoMessage() {}
+
+func (x *Element) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Element.ProtoReflect.Descriptor instead.
+func (*Element) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Element) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Element) GetTier() int32 {
+	if x != nil {
+		return x.Tier
+	}
+	return 0
+}
+
+func (x *Element) GetRecipes() []*Ingredients {
+	if x != nil {
+		return x.Recipes
+	}
+	return nil
+}
+
+func (x *Element) GetUsedIn() []string {
+	if x != nil {
+		return x.UsedIn
+	}
+	return nil
+}
+
+type GetElementRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetElementRequest) Reset() {
+	*x = GetElementRequest{}
+	mi := &file_recipe_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetElementRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetElementRequest) ProtoMessage() {}
+
+func (x *GetElementRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetElementRequest.ProtoReflect.Descriptor instead.
+func (*GetElementRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *GetElementRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+type ListElementsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Only elements whose name starts with prefix.
+	Prefix string `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	// Only elements of this tier.
+	Tier          *int32 `protobuf:"varint,2,opt,name=tier,proto3,oneof" json:"tier,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListElementsRequest) Reset() {
+	*x = ListElementsRequest{}
+	mi := &file_recipe_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListElementsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListElementsRequest) ProtoMessage() {}
+
+func (x *ListElementsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListElementsRequest.ProtoReflect.Descriptor instead.
+func (*ListElementsRequest) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListElementsRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ListElementsRequest) GetTier() int32 {
+	if x != nil && x.Tier != nil {
+		return *x.Tier
+	}
+	return 0
+}
+
+type ListElementsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Elements      []*Element             `protobuf:"bytes,1,rep,name=elements,proto3" json:"elements,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListElementsResponse) Reset() {
+	*x = ListElementsResponse{}
+	mi := &file_recipe_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListElementsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListElementsResponse) ProtoMessage() {}
+
+func (x *ListElementsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_recipe_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListElementsResponse.ProtoReflect.Descriptor instead.
+func (*ListElementsResponse) Descriptor() ([]byte, []int) {
+	return file_recipe_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListElementsResponse) GetElements() []*Element {
+	if x != nil {
+		return x.Elements
+	}
+	return nil
+}
+
+var File_recipe_proto protoreflect.FileDescriptor
+
+const file_recipe_proto_rawDesc = "" +
+	"\n" +
+	"\frecipe.proto\x12\varachemy.v1\x1a\x1egoogle/protobuf/duration.proto\"\xe2\x01\n" +
+	"\vFindRequest\x12\x16\n" +
+	"\x06target\x18\x01 \x01(\tR\x06target\x12\x16\n" +
+	"\x06method\x18\x02 \x01(\tR\x06method\x12\x14\n" +
+	"\x05count\x18\x03 \x01(\x05R\x05count\x12$\n" +
+	"\rbidirectional\x18\x04 \x01(\bR\rbidirectional\x12\x1a\n" +
+	"\boptimize\x18\x05 \x01(\tR\boptimize\x12\x18\n" +
+	"\aweights\x18\x06 \x01(\tR\aweights\x12\x14\n" +
+	"\x05packs\x18\a \x03(\tR\x05packs\x12\x1b\n" +
+	"\tmax_depth\x18\b \x01(\x05R\bmaxDepth\"h\n" +
+	"\n" +
+	"RecipeStep\x12 \n" +
+	"\vingredient1\x18\x01 \x01(\tR\vingredient1\x12 \n" +
+	"\vingredient2\x18\x02 \x01(\tR\vingredient2\x12\x16\n" +
+	"\x06result\x18\x03 \x01(\tR\x06result\"\xc9\x02\n" +
+	"\n" +
+	"RecipePath\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12-\n" +
+	"\x05steps\x18\x02 \x03(\v2\x17.arachemy.v1.RecipeStepR\x05steps\x123\n" +
+	"\aruntime\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\aruntime\x12#\n" +
+	"\rnodes_visited\x18\x04 \x01(\x05R\fnodesVisited\x12\x17\n" +
+	"\x04cost\x18\x05 \x01(\x01H\x00R\x04cost\x88\x01\x01\x12\x15\n" +
+	"\x03gap\x18\x06 \x01(\x01H\x01R\x03gap\x88\x01\x01\x12\x1c\n" +
+	"\tobjective\x18\a \x01(\tR\tobjective\x12=\n" +
+	"\x0enodes_by_depth\x18\b \x03(\v2\x17.arachemy.v1.DepthNodesR\fnodesByDepthB\a\n" +
+	"\x05_costB\x06\n" +
+	"\x04_gap\"8\n" +
+	"\n" +
+	"DepthNodes\x12\x14\n" +
+	"\x05depth\x18\x01 \x01(\x05R\x05depth\x12\x14\n" +
+	"\x05nodes\x18\x02 \x01(\x05R\x05nodes\"\xec\x01\n" +
+	"\fFindResponse\x12\x14\n" +
+	"\x05found\x18\x01 \x01(\bR\x05found\x12-\n" +
+	"\x05paths\x18\x02 \x03(\v2\x17.arachemy.v1.RecipePathR\x05paths\x123\n" +
+	"\aruntime\x18\x03 \x01(\v2\x19.google.protobuf.DurationR\aruntime\x12#\n" +
+	"\rnodes_visited\x18\x04 \x01(\x05R\fnodesVisited\x12=\n" +
+	"\x0enodes_by_depth\x18\x05 \x03(\v2\x17.arachemy.v1.DepthNodesR\fnodesByDepth\"Q\n" +
+	"\vIngredients\x12 \n" +
+	"\vingredient1\x18\x01 \x01(\tR\vingredient1\x12 \n" +
+	"\vingredient2\x18\x02 \x01(\tR\vingredient2\"~\n" +
+	"\aElement\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x12\n" +
+	"\x04tier\x18\x02 \x01(\x05R\x04tier\x122\n" +
+	"\arecipes\x18\x03 \x03(\v2\x18.arachemy.v1.IngredientsR\arecipes\x12\x17\n" +
+	"\aused_in\x18\x04 \x03(\tR\x06usedIn\"'\n" +
+	"\x11GetElementRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\"O\n" +
+	"\x13ListElementsRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12\x17\n" +
+	"\x04tier\x18\x02 \x01(\x05H\x00R\x04tier\x88\x01\x01B\a\n" +
+	"\x05_tier\"H\n" +
+	"\x14ListElementsResponse\x120\n" +
+	"\belements\x18\x01 \x03(\v2\x14.arachemy.v1.ElementR\belements2\xa8\x02\n" +
+	"\rRecipeService\x12;\n" +
+	"\x04Find\x12\x18.arachemy.v1.FindRequest\x1a\x19.arachemy.v1.FindResponse\x12A\n" +
+	"\n" +
+	"StreamFind\x12\x18.arachemy.v1.FindRequest\x1a\x17.arachemy.v1.RecipePath0\x01\x12B\n" +
+	"\n" +
+	"GetElement\x12\x1e.arachemy.v1.GetElementRequest\x1a\x14.arachemy.v1.Element\x12S\n" +
+	"\fListElements\x12 .arachemy.v1.ListElementsRequest\x1a!.arachemy.v1.ListElementsResponseB\x13Z\x11arachemy/recipepbb\x06proto3"
+
+var (
+	file_recipe_proto_rawDescOnce sync.Once
+	file_recipe_proto_rawDescData []byte
+)
+
+func file_recipe_proto_rawDescGZIP() []byte {
+	file_recipe_proto_rawDescOnce.Do(func() {
+		file_recipe_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_recipe_proto_rawDesc), len(file_recipe_proto_rawDesc)))
+	})
+	return file_recipe_proto_rawDescData
+}
+
+var file_recipe_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_recipe_proto_goTypes = []any{
+	(*FindRequest)(nil),          // 0: arachemy.v1.FindRequest
+	(*RecipeStep)(nil),           // 1: arachemy.v1.RecipeStep
+	(*RecipePath)(nil),           // 2: arachemy.v1.RecipePath
+	(*DepthNodes)(nil),           // 3: arachemy.v1.DepthNodes
+	(*FindResponse)(nil),         // 4: arachemy.v1.FindResponse
+	(*Ingredients)(nil),          // 5: arachemy.v1.Ingredients
+	(*Element)(nil),              // 6: arachemy.v1.Element
+	(*GetElementRequest)(nil),    // 7: arachemy.v1.GetElementRequest
+	(*ListElementsRequest)(nil),  // 8: arachemy.v1.ListElementsRequest
+	(*ListElementsResponse)(nil), // 9: arachemy.v1.ListElementsResponse
+	(*durationpb.Duration)(nil),  // 10: google.protobuf.Duration
+}
+var file_recipe_proto_depIdxs = []int32{
+	1,  // 0: arachemy.v1.RecipePath.steps:type_name -> arachemy.v1.RecipeStep
+	10, // 1: arachemy.v1.RecipePath.runtime:type_name -> google.protobuf.Duration
+	3,  // 2: arachemy.v1.RecipePath.nodes_by_depth:type_name -> arachemy.v1.DepthNodes
+	2,  // 3: arachemy.v1.FindResponse.paths:type_name -> arachemy.v1.RecipePath
+	10, // 4: arachemy.v1.FindResponse.runtime:type_name -> google.protobuf.Duration
+	3,  // 5: arachemy.v1.FindResponse.nodes_by_depth:type_name -> arachemy.v1.DepthNodes
+	5,  // 6: arachemy.v1.Element.recipes:type_name -> arachemy.v1.Ingredients
+	6,  // 7: arachemy.v1.ListElementsResponse.elements:type_name -> arachemy.v1.Element
+	0,  // 8: arachemy.v1.RecipeService.Find:input_type -> arachemy.v1.FindRequest
+	0,  // 9: arachemy.v1.RecipeService.StreamFind:input_type -> arachemy.v1.FindRequest
+	7,  // 10: arachemy.v1.RecipeService.GetElement:input_type -> arachemy.v1.GetElementRequest
+	8,  // 11: arachemy.v1.RecipeService.ListElements:input_type -> arachemy.v1.ListElementsRequest
+	4,  // 12: arachemy.v1.RecipeService.Find:output_type -> arachemy.v1.FindResponse
+	2,  // 13: arachemy.v1.RecipeService.StreamFind:output_type -> arachemy.v1.RecipePath
+	6,  // 14: arachemy.v1.RecipeService.GetElement:output_type -> arachemy.v1.Element
+	9,  // 15: arachemy.v1.RecipeService.ListElements:output_type -> arachemy.v1.ListElementsResponse
+	12, // [12:16] is the sub-list for method output_type
+	8,  // [8:12] is the sub-list for method input_type
+	8,  // [8:8] is the sub-list for extension type_name
+	8,  // [8:8] is the sub-list for extension extendee
+	0,  // [0:8] is the sub-list for field type_name
+}
+
+func init() { file_recipe_proto_init() }
+func file_recipe_proto_init() {
+	if File_recipe_proto != nil {
+		return
+	}
+	file_recipe_proto_msgTypes[2].OneofWrappers = []any{}
+	file_recipe_proto_msgTypes[8].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_recipe_proto_rawDesc), len(file_recipe_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   10,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_recipe_proto_goTypes,
+		DependencyIndexes: file_recipe_proto_depIdxs,
+		MessageInfos:      file_recipe_proto_msgTypes,
+	}.Build()
+	File_recipe_proto = out.File
+	file_recipe_proto_goTypes = nil
+	file_recipe_proto_depIdxs = nil
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/chzyer/readline"
+)
+
+// replSession is the state kept between REPL commands
+type replSession struct {
+	method        string
+	count         int
+	bidirectional bool
+	optimize      string
+	format        string
+	history       []string
+}
+
+var replCommands = []string{"find", "uses", "recipes", "tier", "compare", "set", "show", "history", "help", "quit"}
+
+var replSettings = []string{"method", "count", "bidirectional", "optimize", "format"}
+
+const replHelp = `Commands:
+  find <element>       search with the current settings
+  uses <element>       elements that use <element> as an ingredient
+  recipes <element>    every combination that makes <element>
+  tier <element>       tier of <element>
+  compare <element>    run every algorithm on <element> and compare
+  set method bfs|dfs|optimal|exact|astar|iddfs|kbest   set count N   set bidirectional on|off
+  set optimize steps|depth|elements|weighted   set format text|tree
+  show                 current settings
+  history              commands of this session
+  help, quit`
+
+func cmdRepl(args []string) int {
+	fs, data, verbose := newCLIFlags("repl")
+	historyFile := fs.String("history-file", "", "also keep history in this file across sessions")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if err := setupCLI(*data, *verbose); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          "arachemy> ",
+		HistoryFile:     *historyFile,
+		AutoComplete:    replCompleter{},
+		InterruptPrompt: "^C",
+		EOFPrompt:       "quit",
+		Stdout:          cliOut,
+		Stderr:          os.Stderr,
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		return 1
+	}
+	defer rl.Close()
+
+	s := &replSession{method: "bfs", count: 1, optimize: OptimizeSteps, format: "text"}
+	fmt.Fprintf(cliOut, "Loaded %d elements from %s. Type help for commands, TAB completes element names.\n", len(elementNames()), appConfig.DataPath)
+	for {
+		line, err := rl.Readline()
+		if err == readline.ErrInterrupt {
+			continue
+		}
+		if err == io.EOF {
+			return 0
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, "error:", err)
+			return 1
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		s.history = append(s.history, line)
+		if !s.run(line) {
+			return 0
+		}
+	}
+}
+
+// run executes one command line, it returns false to leave the REPL
+func (s *replSession) run(line string) bool {
+	cmd, arg, _ := strings.Cut(line, " ")
+	arg = strings.ToLower(strings.TrimSpace(arg))
+
+	needsElement := map[string]bool{"find": true, "uses": true, "recipes": true, "tier": true, "compare": true}
+	if needsElement[cmd] {
+		if arg == "" {
+			fmt.Fprintf(cliOut, "usage: %s <element>\n", cmd)
+			return true
+		}
+		if _, ok := lookupElement(arg); !ok {
+			fmt.Fprintf(cliOut, "unknown element %q\n", arg)
+			return true
+		}
+	}
+
+	switch cmd {
+	case "find":
+		s.find(arg)
+	case "uses":
+		info, _ := lookupElement(arg)
+		fmt.Fprintf(cliOut, "%s is used in %d elements: %s\n", arg, len(info.UsedIn), strings.Join(info.UsedIn, ", "))
+	case "recipes":
+		info, _ := lookupElement(arg)
+		fmt.Fprintf(cliOut, "%s has %d recipes:\n", arg, len(info.Recipes))
+		for _, r := range info.Recipes {
+			fmt.Fprintf(cliOut, "  %s + %s\n", r[0], r[1])
+		}
+	case "tier":
+		info, _ := lookupElement(arg)
+		fmt.Fprintf(cliOut, "%s is tier %d\n", arg, info.Tier)
+	case "compare":
+		s.compare(arg)
+	case "set":
+		s.set(arg)
+	case "show":
+		fmt.Fprintf(cliOut, "method=%s count=%d bidirectional=%t optimize=%s format=%s\n", s.method, s.count, s.bidirectional, s.optimize, s.format)
+	case "history":
+		for i, h := range s.history {
+			fmt.Fprintf(cliOut, "%4d  %s\n", i+1, h)
+		}
+	case "help":
+		fmt.Fprintln(cliOut, replHelp)
+	case "quit", "exit":
+		return false
+	default:
+		fmt.Fprintf(cliOut, "unknown command %q, type help\n", cmd)
+	}
+	return true
+}
+
+func (s *replSession) find(target string) {
+	req := FindRequest{Target: target, Method: s.method, Count: s.count, Bidirectional: s.bidirectional && s.count == 1,
+		Objective: Objective{Name: s.optimize}}
+	result, err := findRecipes(context.Background(), req, nil)
+	if err != nil {
+		fmt.Fprintln(cliOut, "error:", err)
+		return
+	}
+	if !result.Found {
+		fmt.Fprintf(cliOut, "no recipe found for %s (%s, %d nodes visited)\n", target, result.Runtime, result.NodesVisited)
+		return
+	}
+	for i, p := range result.Paths {
+		fmt.Fprintf(cliOut, "Path %d (%s, %d nodes visited)\n", i+1, p.Runtime, p.NodesVisited)
+		if s.format == "tree" {
+			printRecipeTree(buildRecipeTree(target, p.Steps), "", true, true)
+			continue
+		}
+		for j, step := range p.Steps {
+			fmt.Fprintf(cliOut, "  %d. %s\n", j+1, step)
+		}
+	}
+}
+
+// compare runs every single-path algorithm on target and tabulates the stats
+func (s *replSession) compare(target string) {
+	variants := []FindRequest{
+		{Target: target, Method: "bfs", Count: 1},
+		{Target: target, Method: "bfs", Count: 1, Bidirectional: true},
+		{Target: target, Method: "dfs", Count: 1},
+		{Target: target, Method: "dfs", Count: 1, Bidirectional: true},
+		{Target: target, Method: "optimal", Count: 1},
+		{Target: target, Method: "exact", Count: 1},
+		{Target: target, Method: "astar", Count: 1},
+		{Target: target, Method: "iddfs", Count: 1},
+	}
+	tw := tabwriter.NewWriter(cliOut, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ALGORITHM\tFOUND\tSTEPS\tNODES\tRUNTIME")
+	for _, req := range variants {
+		name := req.Method
+		if req.Bidirectional {
+			name += " (bidirectional)"
+		}
+		result, err := findRecipes(context.Background(), req, nil)
+		if err != nil {
+			fmt.Fprintf(tw, "%s\terror: %v\t\t\t\n", name, err)
+			continue
+		}
+		steps := 0
+		if len(result.Paths) > 0 {
+			steps = len(result.Paths[0].Steps)
+		}
+		fmt.Fprintf(tw, "%s\t%t\t%d\t%d\t%s\n", name, result.Found, steps, result.NodesVisited, result.Runtime)
+	}
+	tw.Flush()
+}
+
+func (s *replSession) set(arg string) {
+	key, value, _ := strings.Cut(arg, " ")
+	value = strings.TrimSpace(value)
+	switch key {
+	case "method":
+		if !containsString(findMethods, value) {
+			fmt.Fprintln(cliOut, "method must be one of", strings.Join(findMethods, ", "))
+			return
+		}
+		s.method = value
+	case "count":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			fmt.Fprintln(cliOut, "count must be a positive number")
+			return
+		}
+		s.count = n
+	case "bidirectional":
+		s.bidirectional = value == "on" || value == "true"
+	case "optimize":
+		if !containsString(optimizeObjectives, value) {
+			fmt.Fprintln(cliOut, "optimize must be one of", strings.Join(optimizeObjectives, ", "))
+			return
+		}
+		s.optimize = value
+	case "format":
+		if value != "text" && value != "tree" {
+			fmt.Fprintln(cliOut, "format must be text or tree")
+			return
+		}
+		s.format = value
+	default:
+		fmt.Fprintf(cliOut, "unknown setting %q, one of: %s\n", key, strings.Join(replSettings, ", "))
+		return
+	}
+	fmt.Fprintf(cliOut, "%s = %s\n", key, value)
+}
+
+// replCompleter completes command names, then element names (which may
+// contain spaces) or setting names after "set"
+type replCompleter struct{}
+
+func (replCompleter) Do(line []rune, pos int) ([][]rune, int) {
+	text := string(line[:pos])
+	cmd, rest, hasArg := strings.Cut(text, " ")
+	if !hasArg {
+		return completeFrom(replCommands, cmd)
+	}
+	switch cmd {
+	case "set":
+		key, value, hasValue := strings.Cut(rest, " ")
+		if !hasValue {
+			return completeFrom(replSettings, key)
+		}
+		switch key {
+		case "method":
+			return completeFrom(findMethods, value)
+		case "optimize":
+			return completeFrom(optimizeObjectives, value)
+		case "bidirectional":
+			return completeFrom([]string{"on", "off"}, value)
+		case "format":
+			return completeFrom([]string{"text", "tree"}, value)
+		}
+		return nil, 0
+	case "find", "uses", "recipes", "tier", "compare":
+		return completeFrom(elementNames(), strings.ToLower(strings.TrimLeft(rest, " ")))
+	}
+	return nil, 0
+}
+
+// completeFrom returns the remaining part of every candidate starting with prefix
+func completeFrom(candidates []string, prefix string) ([][]rune, int) {
+	var out [][]rune
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			out = append(out, []rune(c[len(prefix):]))
+		}
+	}
+	return out, len([]rune(prefix))
+}
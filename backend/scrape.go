@@ -1,15 +1,15 @@
 package main
 
 import (
-	"encoding/json"
+	"bytes"
+	"context"
 	"fmt"
-	"net"
 	"net/http"
-	"os"
+	neturl "net/url"
 	"strings"
 	"time"
 
-	"github.com/gin-gonic/gin"
+	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly"
 )
 
@@ -18,205 +18,123 @@ type RecipeType struct {
 	Ingredient1 string
 	Ingredient2 string
 	Type        int
+	Pack        string
 }
 
-func getElementType(index int) int {
-	switch index {
-	case 1:
-		return 0
-	case 2:
-		// Skip (Ruins/Archeologist)
-		return -1
-	case 3:
-		return 1
-	case 4:
-		return 2
-	case 5:
-		return 3
-	case 6:
-		return 4
-	case 7:
-		return 5
-	case 8:
-		return 6
-	case 9:
-		return 7
-	case 10:
-		return 8
-	case 11:
-		return 9
-	case 12:
-		return 10
-	case 13:
-		return 11
-	case 14:
-		return 12
-	case 15:
-		return 13
-	case 16:
-		return 14
-	case 17:
-		return 15
-	default:
-		return -1
-	}
+// parseElementsTable reads one table: the element in the first column,
+// one recipe per list item in the second
+func parseElementsTable(table *goquery.Selection, elementType int) []RecipeType {
+	var recipes []RecipeType
+	table.Find("tbody tr").Each(func(_ int, row *goquery.Selection) {
+		element := strings.TrimSpace(row.Find("td:first-of-type a").Text())
+		if element == "" {
+			return
+		}
+
+		row.Find("td:nth-of-type(2) li").Each(func(_ int, li *goquery.Selection) {
+			aTags := li.Find("a")
+
+			if aTags.Length() < 4 {
+				return
+			}
+
+			ingredient1 := strings.TrimSpace(aTags.Eq(1).Text())
+			ingredient2 := strings.TrimSpace(aTags.Eq(3).Text())
+
+			recipes = append(recipes, RecipeType{
+				Element:     strings.ToLower(element),
+				Ingredient1: strings.ToLower(ingredient1),
+				Ingredient2: strings.ToLower(ingredient2),
+				Type:        elementType,
+			})
+		})
+	})
+	return recipes
 }
 
-func ScrapeHandler(ctx *gin.Context) {
-	url := "https://little-alchemy.fandom.com/wiki/Elements_(Little_Alchemy_2)"
+// scrapeElements fetches url with colly and parses it with parser.
+// Requests honour robots.txt and go through politeTransport.
+// Progress is reported to job, which may be nil; cancelling ctx aborts the
+// requests in flight. The layout report is nil if the page was never fetched.
+func scrapeElements(ctx context.Context, url string, parser RecipeParser, job *ScrapeJob) ([]RecipeType, *LayoutReport, error) {
 	var recipes []RecipeType
+	var report *LayoutReport
+	var parseErr error
 
-	c := colly.NewCollector(colly.AllowedDomains("little-alchemy.fandom.com"),
+	domain := "little-alchemy.fandom.com"
+	// colly compares the host including the port
+	if u, err := neturl.Parse(url); err == nil && u.Host != "" {
+		domain = u.Host
+	}
+	c := colly.NewCollector(colly.AllowedDomains(domain),
 		// Add timeout settings to avoid long wait times
 		colly.MaxDepth(1),
 		colly.Async(true),
 	)
+	c.IgnoreRobotsTxt = false
+	c.WithTransport(&contextTransport{ctx: ctx, base: newPoliteTransport(job)})
 	// Limit concurrent requests
 	_ = c.Limit(&colly.LimitRule{
 		DomainGlob:  "*",
 		Parallelism: 2,
 		Delay:       1 * time.Second,
 	})
-	tableIndex := 0
 
-	c.OnHTML("table.list-table", func(table *colly.HTMLElement) {
-		tableIndex++
-		elementType := getElementType(tableIndex)
-		if elementType == -1 {
-			return
-		}
-
-		table.ForEach("tbody tr", func(_ int, h *colly.HTMLElement) {
-			element := strings.TrimSpace(h.ChildText("td:first-of-type a"))
-			if element == "" || element == "Time" || element == "Ruins" || element == "Archeologist" {
-				return
-			}
-
-			h.ForEach("td:nth-of-type(2) li", func(_ int, li *colly.HTMLElement) {
-				aTags := li.DOM.Find("a")
-
-				if aTags.Length() < 4 {
-					return
-				}
-
-				ingredient1 := strings.TrimSpace(aTags.Eq(1).Text())
-				ingredient2 := strings.TrimSpace(aTags.Eq(3).Text())
-
-				var ignoredIngredients = map[string]bool{
-					"Time":             true,
-					"Ruins":            true,
-					"Archeologist":     true,
-					"Zeus":             true,
-					"Angel":            true,
-					"Jiangshi":         true,
-					"Monster":          true,
-					"Baba yaga":        true,
-					"Book of the dead": true,
-					"Cockatrice":       true,
-					"Curse":            true,
-					"Deity":            true,
-					"Demon":            true,
-					"Heaven":           true,
-					"Holy grail":       true,
-					"Holy water":       true,
-					"Necromancer":      true,
-					"Paladin":          true,
-					"Selkie":           true,
-					"Troll":            true,
-					"Babe the blue ox": true,
-					"Cosmic egg":       true,
-					"Cupid":            true,
-					"Cyclops":          true,
-					"Dionysus":         true,
-					"Faerie":           true,
-					"Paul bunyan":      true,
-					"Elf":              true,
-					"Maui's fishhook":  true,
-				}
-
-				// Then in your function, replace the long if condition with:
-				if ignoredIngredients[ingredient1] || ignoredIngredients[ingredient2] {
-					return
-				}
-
-				r := RecipeType{
-					Element:     strings.ToLower(element),
-					Ingredient1: strings.ToLower(ingredient1),
-					Ingredient2: strings.ToLower(ingredient2),
-					Type:        elementType,
-				}
-				recipes = append(recipes, r)
-			})
-		})
+	c.OnResponse(func(r *colly.Response) {
+		job.pageFetched()
+		recipes, report, parseErr = parser.Parse(bytes.NewReader(r.Body))
+		job.recipesParsed(len(recipes))
 	})
 
 	c.OnRequest(func(r *colly.Request) {
+		if ctx.Err() != nil {
+			r.Abort()
+			return
+		}
 		fmt.Print("Visiting ", r.URL)
 	})
 
 	c.OnError(func(r *colly.Response, e error) {
+		// Transient errors were already retried by politeTransport
 		fmt.Println("Error:", e.Error())
-		// Check for specific network/TCP errors
-		if strings.Contains(e.Error(), "dial tcp") ||
-			strings.Contains(e.Error(), "context deadline exceeded") ||
-			strings.Contains(e.Error(), "i/o timeout") {
-
-			// Log specific TCP error message
-			fmt.Printf("TCP connection failed: %s. Retrying...\n", e.Error())
-
-			// You could implement retry logic here
-			// For example, try an alternative domain or proxy
-
-			// Optional: Retry with a different transport
-			transport := &http.Transport{
-				Dial: (&net.Dialer{
-					Timeout:   30 * time.Second,
-					KeepAlive: 30 * time.Second,
-				}).Dial,
-				TLSHandshakeTimeout:   15 * time.Second,
-				ResponseHeaderTimeout: 15 * time.Second,
-				ExpectContinueTimeout: 1 * time.Second,
-			}
-
-			c.WithTransport(transport)
-
-			// Optional: Try with a backup URL if available
-			// c.Visit(backupUrl)
-		}
+		job.addError(e.Error())
 	})
 
-	err := c.Visit(url)
-	if err != nil {
-		if strings.Contains(err.Error(), "dial tcp") ||
-			strings.Contains(err.Error(), "context deadline exceeded") ||
-			strings.Contains(err.Error(), "i/o timeout") {
-			ctx.JSON(http.StatusServiceUnavailable, gin.H{
-				"error":   "Network connection failed. Please check your internet connection and try again later.",
-				"details": err.Error(),
-			})
-		} else {
-			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := c.Visit(url); err != nil {
+		if err == colly.ErrRobotsTxtBlocked {
+			return nil, nil, newAPIError(ErrScrapeFailed, url+" is disallowed by robots.txt")
+		}
+		if isNetworkError(err) {
+			return nil, nil, newAPIError(ErrScrapeNetwork, err.Error())
 		}
-		return
+		return nil, nil, newAPIError(ErrScrapeFailed, err.Error())
 	}
 	// Wait for all requests to finish
 	c.Wait()
-	if err := os.MkdirAll("data", 0755); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create data directory"})
-		return
+	if ctx.Err() != nil {
+		return nil, report, ctx.Err()
 	}
-
-	filePath := "data/recipes.json"
-	jsonBytes, err := json.Marshal(recipes)
-	if err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to marshal recipes to JSON"})
-		return
-	}
-	if err := os.WriteFile(filePath, jsonBytes, 0644); err != nil {
-		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save recipes"})
-		return
+	if _, ok := parseErr.(*APIError); ok {
+		return nil, report, parseErr
+	} else if parseErr != nil {
+		return nil, report, newAPIError(ErrScrapeFailed, parseErr.Error())
 	}
+	return recipes, report, nil
+}
+
+// contextTransport ties every request of a collector to ctx, colly itself
+// has no notion of cancellation
+type contextTransport struct {
+	ctx  context.Context
+	base http.RoundTripper
+}
+
+func (t *contextTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return t.base.RoundTrip(req.WithContext(t.ctx))
+}
 
-	ctx.SetCookie("scraped", "true", 86400, "/", "localhost", false, true)
-	ctx.JSON(http.StatusOK, gin.H{"data": recipes})
+func isNetworkError(err error) bool {
+	return strings.Contains(err.Error(), "dial tcp") ||
+		strings.Contains(err.Error(), "context deadline exceeded") ||
+		strings.Contains(err.Error(), "i/o timeout")
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+)
+
+// readExpected loads a fixture's expected recipes
+func readExpected(t *testing.T, path string) []RecipeType {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want []RecipeType
+	if err := json.Unmarshal(data, &want); err != nil {
+		t.Fatalf("%s: %v", path, err)
+	}
+	return want
+}
+
+// parseFixture parses an elements page fixture with the parser of source
+func parseFixture(t *testing.T, source, path string) ([]RecipeType, *LayoutReport, error) {
+	t.Helper()
+	p, err := lookupParser(source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return p.Parse(f)
+}
+
+func TestParseElementsPage(t *testing.T) {
+	tests := []struct {
+		source   string
+		page     string
+		expected string
+	}{
+		{"la2", "testdata/elements_page.html", "testdata/elements_page.expected.json"},
+		{"la1", "testdata/la1_elements_page.html", "testdata/la1_elements_page.expected.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.source, func(t *testing.T) {
+			got, report, err := parseFixture(t, tt.source, tt.page)
+			if err != nil {
+				t.Fatalf("parse %s: %v", tt.page, err)
+			}
+			if len(report.Errors) > 0 {
+				t.Errorf("layout errors: %v", report.Errors)
+			}
+			if diff := diffRecipes(readExpected(t, tt.expected), got); len(diff) > 0 {
+				t.Errorf("recipes differ from %s:\n%v", tt.expected, diff)
+			}
+		})
+	}
+}
+
+func TestParseElementsPageBadLayout(t *testing.T) {
+	_, report, err := parseFixture(t, "la2", "testdata/elements_page_bad_layout.html")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.Code != ErrScrapeLayout {
+		t.Fatalf("want %s, got %v", ErrScrapeLayout, err)
+	}
+	if report == nil || len(report.Errors) == 0 {
+		t.Error("want the layout errors in the report")
+	}
+}
@@ -0,0 +1,344 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Scrape job states
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// ScrapeJob is one background crawl started by POST /admin/scrape
+type ScrapeJob struct {
+	ID             string
+	State          string
+	Source         string
+	URL            string
+	Deep           bool
+	Images         bool
+	StartedAt      time.Time
+	FinishedAt     *time.Time
+	PagesFetched   int
+	RecipesParsed  int
+	Retries        int
+	CachedPages    int
+	Errors         []string
+	Warnings       []string
+	DatasetVersion string
+	Layout         *LayoutReport
+	Overrides      *OverrideReport // overrides applied to the new dataset
+	ImageReport    *ImageReport
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	parser RecipeParser
+}
+
+// scrapeJobs keeps every job of this process, only one may run at a time
+var scrapeJobs = struct {
+	sync.Mutex
+	byID    map[string]*ScrapeJob
+	running *ScrapeJob
+}{byID: make(map[string]*ScrapeJob)}
+
+// The progress methods accept a nil job so the CLI can scrape without one
+
+func (j *ScrapeJob) pageFetched() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.PagesFetched++
+}
+
+func (j *ScrapeJob) recipesParsed(n int) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.RecipesParsed += n
+}
+
+func (j *ScrapeJob) retried() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Retries++
+}
+
+// cacheHit counts a page answered 304 Not Modified and served from the cache
+func (j *ScrapeJob) cacheHit() {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.CachedPages++
+}
+
+func (j *ScrapeJob) addError(msg string) {
+	if j == nil {
+		return
+	}
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Errors = append(j.Errors, msg)
+}
+
+// snapshot copies the job under its lock for serialization
+func (j *ScrapeJob) snapshot() gin.H {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	out := gin.H{
+		"id":            j.ID,
+		"state":         j.State,
+		"source":        j.Source,
+		"url":           j.URL,
+		"deep":          j.Deep,
+		"images":        j.Images,
+		"startedAt":     j.StartedAt,
+		"pagesFetched":  j.PagesFetched,
+		"recipesParsed": j.RecipesParsed,
+		"retries":       j.Retries,
+		"cachedPages":   j.CachedPages,
+		"errors":        append([]string{}, j.Errors...),
+		"warnings":      append([]string{}, j.Warnings...),
+	}
+	if j.FinishedAt != nil {
+		out["finishedAt"] = *j.FinishedAt
+		out["duration"] = j.FinishedAt.Sub(j.StartedAt).String()
+	}
+	if j.DatasetVersion != "" {
+		out["datasetVersion"] = j.DatasetVersion
+	}
+	if j.Layout != nil {
+		out["layout"] = j.Layout
+	}
+	if j.Overrides != nil {
+		out["overrides"] = j.Overrides
+	}
+	if j.ImageReport != nil {
+		out["imageReport"] = j.ImageReport
+	}
+	return out
+}
+
+// startScrapeJob starts a crawl of the page of parser in the background,
+// deep also crawls every element page and images downloads their icons (it
+// implies deep). If a job is already running it is returned with started
+// set to false.
+func startScrapeJob(parser RecipeParser, deep, images bool) (job *ScrapeJob, started bool) {
+	scrapeJobs.Lock()
+	defer scrapeJobs.Unlock()
+	if scrapeJobs.running != nil {
+		return scrapeJobs.running, false
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job = &ScrapeJob{
+		ID:        newJobID(),
+		State:     JobRunning,
+		Source:    parser.Name(),
+		URL:       parser.URL(),
+		Deep:      deep || images,
+		Images:    images,
+		StartedAt: time.Now(),
+		Errors:    []string{},
+		Warnings:  []string{},
+		cancel:    cancel,
+		parser:    parser,
+	}
+	scrapeJobs.byID[job.ID] = job
+	scrapeJobs.running = job
+
+	go job.run(ctx)
+	return job, true
+}
+
+// run scrapes, saves the dataset of the source (and its elements.json for a
+// deep crawl, the image store index when downloading icons) and reloads it
+// if it is the dataset the server uses
+func (j *ScrapeJob) run(ctx context.Context) {
+	fmt.Printf("[SCRAPE] Job %s started: %s\n", j.ID, j.URL)
+	state := JobSucceeded
+	version := ""
+
+	recipes, layout, err := scrapeElements(ctx, j.URL, j.parser, j)
+	j.mu.Lock()
+	j.Layout = layout
+	j.mu.Unlock()
+	var details []ElementDetails
+	if err == nil && j.Deep {
+		details = crawlElementPages(ctx, j.URL, layout.Links, j)
+		warnings := checkUsedToCreate(details, recipes)
+		j.mu.Lock()
+		j.Warnings = append(j.Warnings, warnings...)
+		j.mu.Unlock()
+		err = ctx.Err()
+	}
+	var images map[string]StoredImage
+	if err == nil && j.Images {
+		images = downloadElementImages(ctx, appConfig.ImageStore, details, j)
+		err = ctx.Err()
+	}
+	// A layout mismatch is an error too, the dataset is only written
+	// when the page looked as expected
+	if err == nil {
+		version, err = saveRecipes(sourceDataPath(j.Source), recipes, false)
+	}
+	if err == nil && j.Deep {
+		err = saveElementDetails(sourceElementsPath(j.Source), details)
+	}
+	if err == nil && j.Images {
+		err = saveImageIndex(appConfig.ImageStore, images)
+	}
+	served := j.Source == DefaultSource
+	if err == nil && served {
+		err = loadDataset(appConfig.DataPath)
+	}
+	if err == nil && served {
+		// The scrape replaced the upstream data the overrides were written for
+		mutex.RLock()
+		report := overrideReport
+		var imageReport *ImageReport
+		if j.Images {
+			r := checkImages(datasetElements(loadedRecipes), appConfig.ImageStore, storedImages)
+			imageReport = &r
+		}
+		mutex.RUnlock()
+		j.mu.Lock()
+		j.Overrides = report
+		j.ImageReport = imageReport
+		j.mu.Unlock()
+	}
+	if err != nil && errors.Is(ctx.Err(), context.Canceled) {
+		state = JobCancelled
+	} else if err != nil {
+		state = JobFailed
+		j.addError(err.Error())
+	}
+
+	j.mu.Lock()
+	now := time.Now()
+	j.State = state
+	j.FinishedAt = &now
+	j.DatasetVersion = version
+	j.mu.Unlock()
+	j.cancel()
+
+	scrapeJobs.Lock()
+	scrapeJobs.running = nil
+	scrapeJobs.Unlock()
+	fmt.Printf("[SCRAPE] Job %s %s after %s\n", j.ID, state, now.Sub(j.StartedAt))
+}
+
+// ListSourcesHandler handles GET /admin/sources, the registered parsers
+func ListSourcesHandler(c *gin.Context) {
+	sources := []gin.H{}
+	for _, name := range parserNames() {
+		p := recipeParsers[name]
+		sources = append(sources, gin.H{
+			"name":     name,
+			"title":    p.Title(),
+			"url":      p.URL(),
+			"dataPath": sourceDataPath(name),
+			"default":  name == DefaultSource,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"sources": sources})
+}
+
+func newJobID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func findScrapeJob(id string) (*ScrapeJob, bool) {
+	scrapeJobs.Lock()
+	defer scrapeJobs.Unlock()
+	job, ok := scrapeJobs.byID[id]
+	return job, ok
+}
+
+// StartScrapeHandler handles POST /admin/scrape[?source=][&deep=true][&images=true],
+// it answers 202 with the job or 409 naming the job already running, the
+// Location header points to the job either way
+func StartScrapeHandler(c *gin.Context) {
+	parser, err := lookupParser(c.Query("source"))
+	if err != nil {
+		respondError(c, err)
+		return
+	}
+	images := c.Query("images") == "true"
+	if images && parser.Name() != DefaultSource {
+		// The image store belongs to the served dataset
+		respondError(c, newAPIError(ErrInvalidSource, "icons are only downloaded for "+DefaultSource))
+		return
+	}
+	job, started := startScrapeJob(parser, c.Query("deep") == "true", images)
+	c.Header("Location", "/admin/scrape/"+job.ID)
+	if !started {
+		respondError(c, newAPIError(ErrScrapeInProgress, "job "+job.ID))
+		return
+	}
+	c.JSON(http.StatusAccepted, job.snapshot())
+}
+
+// ListScrapeJobsHandler handles GET /admin/scrape, newest job first
+func ListScrapeJobsHandler(c *gin.Context) {
+	scrapeJobs.Lock()
+	jobs := make([]*ScrapeJob, 0, len(scrapeJobs.byID))
+	for _, job := range scrapeJobs.byID {
+		jobs = append(jobs, job)
+	}
+	scrapeJobs.Unlock()
+	sort.Slice(jobs, func(i, k int) bool { return jobs[i].StartedAt.After(jobs[k].StartedAt) })
+
+	out := make([]gin.H, 0, len(jobs))
+	for _, job := range jobs {
+		out = append(out, job.snapshot())
+	}
+	c.JSON(http.StatusOK, gin.H{"data": out})
+}
+
+// ScrapeJobHandler handles GET /admin/scrape/:id
+func ScrapeJobHandler(c *gin.Context) {
+	job, ok := findScrapeJob(c.Param("id"))
+	if !ok {
+		respondError(c, newAPIError(ErrScrapeJobNotFound, c.Param("id")))
+		return
+	}
+	c.JSON(http.StatusOK, job.snapshot())
+}
+
+// CancelScrapeJobHandler handles DELETE /admin/scrape/:id. Cancelling a
+// finished job does nothing and returns it unchanged.
+func CancelScrapeJobHandler(c *gin.Context) {
+	job, ok := findScrapeJob(c.Param("id"))
+	if !ok {
+		respondError(c, newAPIError(ErrScrapeJobNotFound, c.Param("id")))
+		return
+	}
+	job.cancel()
+	fmt.Printf("[SCRAPE] Job %s cancel requested\n", job.ID)
+	c.JSON(http.StatusOK, job.snapshot())
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// stubParser is a source whose page makes steam whatever it holds
+type stubParser struct {
+	url string
+}
+
+func (p stubParser) Name() string  { return "stub" }
+func (p stubParser) Title() string { return "Stub" }
+func (p stubParser) URL() string   { return p.url }
+
+func (p stubParser) Parse(r io.Reader) ([]RecipeType, *LayoutReport, error) {
+	if _, err := io.ReadAll(r); err != nil {
+		return nil, nil, err
+	}
+	return []RecipeType{{Element: "steam", Ingredient1: "fire", Ingredient2: "water", Type: 1}}, &LayoutReport{}, nil
+}
+
+// useStubSource registers stubParser for a page that is only served once
+// release is closed, and writes its dataset to a temp dir
+func useStubSource(t *testing.T) (release chan struct{}) {
+	t.Helper()
+	release = make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/elements" {
+			http.NotFound(w, r)
+			return
+		}
+		select {
+		case <-release:
+			io.WriteString(w, "<table></table>")
+		case <-r.Context().Done():
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	old := appConfig
+	c := *appConfig
+	c.DataPath = filepath.Join(t.TempDir(), "recipes.json")
+	c.MinElements = 0
+	c.HTTPCache = false
+	c.Retries = 0
+	appConfig = &c
+	recipeParsers["stub"] = stubParser{url: srv.URL + "/elements"}
+	t.Cleanup(func() {
+		delete(recipeParsers, "stub")
+		appConfig = old
+	})
+	return release
+}
+
+// adminRequest sends method path with the admin key
+func adminRequest(r *gin.Engine, method, path string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	req.Header.Set("X-API-Key", "admin-key")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// jobState returns the state of job id
+func jobState(t *testing.T, r *gin.Engine, id string) string {
+	t.Helper()
+	w := adminRequest(r, http.MethodGet, "/admin/scrape/"+id)
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET job %s: %d %s", id, w.Code, w.Body)
+	}
+	var job struct {
+		State string `json:"state"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	}
+	return job.State
+}
+
+// waitForJob waits for job id to finish and returns its state
+func waitForJob(t *testing.T, r *gin.Engine, id string) string {
+	t.Helper()
+	deadline := time.Now().Add(20 * time.Second)
+	for time.Now().Before(deadline) {
+		if state := jobState(t, r, id); state != JobRunning {
+			return state
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("job %s still running", id)
+	return ""
+}
+
+// startJob posts a scrape of the stub source and returns the job ID
+func startJob(t *testing.T, r *gin.Engine) string {
+	t.Helper()
+	w := adminRequest(r, http.MethodPost, "/admin/scrape?source=stub")
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("start: %d %s", w.Code, w.Body)
+	}
+	var job struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &job); err != nil {
+		t.Fatal(err)
+	}
+	if loc := w.Header().Get("Location"); loc != "/admin/scrape/"+job.ID {
+		t.Errorf("Location %q, want the job", loc)
+	}
+	return job.ID
+}
+
+func TestScrapeJobLifecycle(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useAPIKeys(t, false)
+	release := useStubSource(t)
+	r := newRouter(appConfig)
+
+	id := startJob(t, r)
+	if state := jobState(t, r, id); state != JobRunning {
+		t.Fatalf("new job is %s", state)
+	}
+
+	w := adminRequest(r, http.MethodPost, "/admin/scrape?source=stub")
+	if w.Code != http.StatusConflict {
+		t.Fatalf("second start: %d %s", w.Code, w.Body)
+	}
+	var conflict struct {
+		Error struct {
+			Code    ErrorCode `json:"code"`
+			Details string    `json:"details"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &conflict); err != nil {
+		t.Fatal(err)
+	}
+	if conflict.Error.Code != ErrScrapeInProgress || !strings.Contains(conflict.Error.Details, id) {
+		t.Errorf("conflict %+v, want %s naming job %s", conflict.Error, ErrScrapeInProgress, id)
+	}
+	if loc := w.Header().Get("Location"); loc != "/admin/scrape/"+id {
+		t.Errorf("conflict Location %q, want the running job", loc)
+	}
+
+	close(release)
+	if state := waitForJob(t, r, id); state != JobSucceeded {
+		t.Fatalf("job %s, want %s", state, JobSucceeded)
+	}
+	if got, err := loadRecipes(sourceDataPath("stub")); err != nil || len(got) != 1 {
+		t.Errorf("saved %d recipes (%v), want the scraped one", len(got), err)
+	}
+
+	w = adminRequest(r, http.MethodGet, "/admin/scrape")
+	var list struct {
+		Data []struct {
+			ID string `json:"id"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &list); err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, job := range list.Data {
+		found = found || job.ID == id
+	}
+	if !found {
+		t.Errorf("job %s missing from the list", id)
+	}
+
+	w = adminRequest(r, http.MethodGet, "/admin/scrape/nope")
+	if w.Code != http.StatusNotFound {
+		t.Errorf("unknown job: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestScrapeJobCancel(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	useAPIKeys(t, false)
+	useStubSource(t)
+	r := newRouter(appConfig)
+
+	id := startJob(t, r)
+	w := adminRequest(r, http.MethodDelete, "/admin/scrape/"+id)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cancel: %d %s", w.Code, w.Body)
+	}
+	if state := waitForJob(t, r, id); state != JobCancelled {
+		t.Fatalf("job %s, want %s", state, JobCancelled)
+	}
+	if _, err := loadRecipes(sourceDataPath("stub")); err == nil {
+		t.Error("a cancelled job saved its dataset")
+	}
+
+	// Nothing runs any more, a new job can start
+	next := startJob(t, r)
+	adminRequest(r, http.MethodDelete, "/admin/scrape/"+next)
+	waitForJob(t, r, next)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// LayoutSection is one heading of the elements page and the tables under it
+type LayoutSection struct {
+	Heading string `json:"heading"`
+	Tier    int    `json:"tier"`
+	// Inferred sections have no tier of their own, each element gets one
+	// more than its highest ingredient
+	Inferred bool `json:"inferred,omitempty"`
+	Skipped  bool `json:"skipped,omitempty"`
+	Tables   int  `json:"tables"`
+	Recipes  int  `json:"recipes"`
+}
+
+// LayoutReport describes how the sections of the elements page were mapped
+// to tiers. Errors mean the page no longer looks like we expect and
+// nothing parsed from it may be saved.
+type LayoutReport struct {
+	Sections []LayoutSection `json:"sections"`
+	Errors   []string        `json:"errors"`
+	Warnings []string        `json:"warnings"`
+	// Links maps every element to the href of its own wiki page
+	Links map[string]string `json:"-"`
+}
+
+// OK reports whether the layout matched
+func (r *LayoutReport) OK() bool {
+	return len(r.Errors) == 0
+}
+
+var tierHeading = regexp.MustCompile(`^tier (\d+) elements?$`)
+
+// headingTier maps a section heading to the tier of its elements. Special
+// elements (time, ruins...) have no tier on the page, theirs is inferred;
+// ok is false for headings we do not recognise.
+func headingTier(heading string) (tier int, inferred bool, ok bool) {
+	switch heading {
+	case "starting elements", "starting element", "basic elements":
+		return 0, false, true
+	case "special elements", "special element":
+		return -1, true, true
+	}
+	if m := tierHeading.FindStringSubmatch(heading); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		return n, false, true
+	}
+	return -1, false, false
+}
+
+// headingText is the normalized text of an h2/h3, without the [edit] link
+func headingText(h *goquery.Selection) string {
+	text := h.Find(".mw-headline").First().Text()
+	if text == "" {
+		text = h.Text()
+	}
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
+
+// parseElementsPage parses the HTML of the wiki elements page. The error is
+// an ErrScrapeLayout APIError when the report has errors.
+func parseElementsPage(r io.Reader) ([]RecipeType, *LayoutReport, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	recipes, report := parseElementsDocument(doc.Selection)
+	inferTiers(recipes)
+	tagRecipePacks(recipes, appConfig.Packs)
+	if !report.OK() {
+		return recipes, report, newAPIError(ErrScrapeLayout, strings.Join(report.Errors, "; "))
+	}
+	return recipes, report, nil
+}
+
+// parseElementsDocument walks the headings and table.list-table elements in
+// page order; each table takes its tier from the closest heading above it
+func parseElementsDocument(doc *goquery.Selection) ([]RecipeType, *LayoutReport) {
+	report := &LayoutReport{Sections: []LayoutSection{}, Errors: []string{}, Warnings: []string{}, Links: make(map[string]string)}
+	var recipes []RecipeType
+	var current *LayoutSection
+	unknown := false
+	tierSeen := make(map[int]string)
+
+	doc.Find("h2, h3, table.list-table").Each(func(_ int, s *goquery.Selection) {
+		if !s.Is("table") {
+			heading := headingText(s)
+			tier, inferred, ok := headingTier(heading)
+			if !ok {
+				// Only a problem if a table follows, checked below
+				current = &LayoutSection{Heading: heading, Tier: -1}
+				unknown = true
+				return
+			}
+			report.Sections = append(report.Sections, LayoutSection{Heading: heading, Tier: tier, Inferred: inferred})
+			current = &report.Sections[len(report.Sections)-1]
+			unknown = false
+			if !inferred {
+				if prev, dup := tierSeen[tier]; dup {
+					report.Errors = append(report.Errors, fmt.Sprintf("tier %d appears under %q and %q", tier, prev, heading))
+				}
+				tierSeen[tier] = heading
+			}
+			return
+		}
+
+		switch {
+		case current == nil:
+			report.Errors = append(report.Errors, "element table before any section heading")
+			return
+		case unknown:
+			// Report each unknown section once, with the tables under it
+			report.Errors = append(report.Errors, fmt.Sprintf("element table under unrecognised heading %q", current.Heading))
+			report.Sections = append(report.Sections, LayoutSection{Heading: current.Heading, Tier: -1, Skipped: true})
+			current = &report.Sections[len(report.Sections)-1]
+			unknown = false
+		}
+		current.Tables++
+		if current.Skipped {
+			return
+		}
+		collectElementLinks(s, report.Links)
+		parsed := parseElementsTable(s, current.Tier)
+		current.Recipes += len(parsed)
+		recipes = append(recipes, parsed...)
+	})
+
+	if _, ok := tierSeen[0]; !ok {
+		report.Errors = append(report.Errors, "no starting elements section")
+	}
+	maxTier := 0
+	for tier := range tierSeen {
+		if tier > maxTier {
+			maxTier = tier
+		}
+	}
+	if maxTier == 0 {
+		report.Errors = append(report.Errors, "no tier sections")
+	}
+	for tier := 1; tier < maxTier; tier++ {
+		if _, ok := tierSeen[tier]; !ok {
+			report.Errors = append(report.Errors, fmt.Sprintf("tier %d section is missing (highest is %d)", tier, maxTier))
+		}
+	}
+	for _, section := range report.Sections {
+		if !section.Skipped && section.Tables == 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("section %q has no element table", section.Heading))
+		} else if !section.Skipped && section.Tier != 0 && section.Recipes == 0 {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("section %q has no recipes", section.Heading))
+		}
+	}
+	return recipes, report
+}
+
+// inferTiers gives the recipes of inferred sections (Type -1) the tier one
+// above their highest ingredient, taking the lowest over the recipes of an
+// element. Ingredients with no known tier count as tier 0.
+func inferTiers(recipes []RecipeType) {
+	tiers := make(map[string]int)
+	for _, r := range recipes {
+		if r.Type >= 0 {
+			tiers[r.Element] = r.Type
+		}
+	}
+	inferred := make(map[string]int)
+	for changed := true; changed; {
+		changed = false
+		for _, r := range recipes {
+			if r.Type >= 0 {
+				continue
+			}
+			t := tiers[r.Ingredient1]
+			if t2 := tiers[r.Ingredient2]; t2 > t {
+				t = t2
+			}
+			t++
+			if old, ok := inferred[r.Element]; !ok || t < old {
+				inferred[r.Element] = t
+				tiers[r.Element] = t
+				changed = true
+			}
+		}
+	}
+	for i := range recipes {
+		if recipes[i].Type < 0 {
+			recipes[i].Type = inferred[recipes[i].Element]
+		}
+	}
+}
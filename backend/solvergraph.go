@@ -0,0 +1,161 @@
+package main
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"math/big"
+	"sort"
+	"sync"
+)
+
+// solverRecipe is one combination of the recipe graph: a + b = element
+type solverRecipe struct {
+	element, a, b string
+}
+
+func (r solverRecipe) step() string {
+	return fmt.Sprintf("%s + %s = %s", r.a, r.b, r.element)
+}
+
+// validRecipes returns the recipes of element whose ingredients are both of
+// a lower tier, the rule every solver follows. With it the recipe graph is
+// a DAG. The caller holds mutex.
+func validRecipes(element string) []solverRecipe {
+	tier := tierMap[element]
+	var out []solverRecipe
+	for _, ingr := range recipesMap[element] {
+		if tierMap[ingr[0]] >= tier || tierMap[ingr[1]] >= tier {
+			continue
+		}
+		out = append(out, solverRecipe{element: element, a: ingr[0], b: ingr[1]})
+	}
+	return out
+}
+
+// recipeUses maps every element to the valid recipes it is an ingredient
+// of. The caller holds mutex.
+func recipeUses() map[string][]solverRecipe {
+	uses := make(map[string][]solverRecipe)
+	elements := make([]string, 0, len(recipesMap))
+	for e := range recipesMap {
+		elements = append(elements, e)
+	}
+	sort.Strings(elements) // deterministic tie-breaking
+	for _, e := range elements {
+		for _, r := range validRecipes(e) {
+			uses[r.a] = append(uses[r.a], r)
+			if r.b != r.a {
+				uses[r.b] = append(uses[r.b], r)
+			}
+		}
+	}
+	return uses
+}
+
+// treeSteps lists the steps of the recipe tree that makes target with the
+// recipe chosen for every element, ingredients before results. An element
+// used twice appears twice, like reconstructPath.
+func treeSteps(target string, chosen map[string]solverRecipe) []string {
+	var steps []string
+	var build func(element string)
+	build = func(element string) {
+		r, ok := chosen[element]
+		if baseElements[element] || !ok {
+			return
+		}
+		build(r.a)
+		build(r.b)
+		steps = append(steps, r.step())
+	}
+	build(target)
+	if steps == nil {
+		steps = []string{}
+	}
+	return steps
+}
+
+// distinctTreeSteps is treeSteps with every element made once: the
+// combinations actually needed, since a crafted element is not consumed
+func distinctTreeSteps(target string, chosen map[string]solverRecipe) []string {
+	steps := []string{}
+	made := make(map[string]bool)
+	var build func(element string)
+	build = func(element string) {
+		r, ok := chosen[element]
+		if baseElements[element] || !ok || made[element] {
+			return
+		}
+		made[element] = true
+		build(r.a)
+		build(r.b)
+		steps = append(steps, r.step())
+	}
+	build(target)
+	return steps
+}
+
+// graphData is what the solvers precompute over the whole recipe graph
+type graphData struct {
+	// minSteps is knuthMinCosts over every element: the fewest steps of a
+	// recipe tree and the recipe achieving it
+	minSteps *knuthResult
+	// minDepth is knuthMinCosts with OptimizeDepth: the depth of the
+	// shallowest recipe tree
+	minDepth *knuthResult
+	// treeCounts is countRecipeTrees without exclusions
+	treeCounts map[string]*big.Int
+}
+
+// graphCache keeps the graphData of the current recipe maps, it is
+// recomputed when graphGeneration moves on
+var graphCache = struct {
+	sync.Mutex
+	generation int
+	data       *graphData
+}{}
+
+// cachedGraph returns the graphData of the current recipe maps. The caller
+// holds mutex.
+func cachedGraph() *graphData {
+	graphCache.Lock()
+	defer graphCache.Unlock()
+	if graphCache.data == nil || graphCache.generation != graphGeneration {
+		graphCache.data = &graphData{
+			minSteps:   knuthMinCosts(context.Background(), "", Objective{Name: OptimizeSteps}),
+			minDepth:   knuthMinCosts(context.Background(), "", Objective{Name: OptimizeDepth}),
+			treeCounts: countRecipeTrees(nil),
+		}
+		graphCache.generation = graphGeneration
+	}
+	return graphCache.data
+}
+
+// costItem is an entry of costQueue
+type costItem struct {
+	cost    float64
+	element string
+	recipe  *solverRecipe // nil for base elements
+}
+
+// costQueue is a min-heap of costItem, ties broken by element name so
+// results do not depend on map order
+type costQueue []costItem
+
+func (q costQueue) Len() int { return len(q) }
+func (q costQueue) Less(i, j int) bool {
+	if q[i].cost != q[j].cost {
+		return q[i].cost < q[j].cost
+	}
+	return q[i].element < q[j].element
+}
+func (q costQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *costQueue) Push(x interface{}) { *q = append(*q, x.(costItem)) }
+func (q *costQueue) Pop() interface{} {
+	old := *q
+	item := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return item
+}
+
+var _ heap.Interface = (*costQueue)(nil)
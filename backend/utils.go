@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 	"sync"
 )
@@ -14,6 +15,7 @@ type Recipe struct {
 	Ingredient1 string `json:"Ingredient1"`
 	Ingredient2 string `json:"Ingredient2"`
 	Type        int    `json:"Type"`
+	Pack        string `json:"Pack,omitempty"`
 }
 
 // Job represents a single task to be processed by a worker
@@ -21,6 +23,8 @@ type Job struct {
 	JobID   int
 	JobType string
 	Target  string
+	// Limit is the most paths the job returns, 0 means maxResults
+	Limit int
 }
 
 type Result struct {
@@ -28,6 +32,10 @@ type Result struct {
 	Steps []string `json:"steps"`
 	Runtime string `json:"runtime"`
 	NodesVisited int `json:"nodesVisited"`
+	Cost *float64 `json:"cost,omitempty"`
+	Gap *float64 `json:"gap,omitempty"`
+	Objective string `json:"objective,omitempty"`
+	NodesByDepth []DepthNodes `json:"nodesByDepth,omitempty"`
 }
 
 // Global variables for recipe data
@@ -35,6 +43,15 @@ var (
 	recipesMap   map[string][][]string
 	tierMap      map[string]int
 	revGraph     map[string][]string
+	elementImages map[string]string // element -> image file name
+	storedImages map[string]StoredImage // element -> icon in the image store
+	elementDetails map[string]ElementDetails // element -> deep crawl data
+	loadedRecipes []Recipe // every recipe of the dataset, all packs
+	activePacks   []string // packs whose recipes are in recipesMap
+	elementAliases map[string]string // alias -> element, from the overrides
+	elementWeights map[string]float64 // element -> weight, from the overrides
+	overrideReport *OverrideReport   // outcome of the overrides at the last load
+	graphGeneration int // bumped whenever recipesMap is rebuilt, see graphCache
 	baseElements = map[string]bool{
 		"fire": true, "water": true, "earth": true, "air": true, "time": true,
 	}
@@ -63,11 +80,83 @@ func loadRecipes(file string) ([]Recipe, error) {
 	return recipes, nil
 }
 
+// loadDataset (re)loads the recipe file, applies the overrides and rebuilds
+// every lookup map
+func loadDataset(file string) error {
+	patched, err := loadPatchedRecipes(file)
+	if err != nil {
+		return err
+	}
+	recipes := patched.recipes
+	images, err := loadElementImages(appConfig.ImageMapPath)
+	if err != nil {
+		fmt.Printf("[ERROR] Failed to load element images: %v\n", err)
+	}
+	stored, err := loadImageIndex(appConfig.ImageStore)
+	if err != nil {
+		fmt.Printf("[ERROR] Failed to load image store index: %v\n", err)
+	}
+	details, err := loadElementDetails(appConfig.ElementsPath)
+	if err != nil {
+		fmt.Printf("[ERROR] Failed to load element details: %v\n", err)
+	}
+	mutex.Lock()
+	defer mutex.Unlock()
+	if activePacks == nil {
+		activePacks, _ = parsePacks(strings.Join(appConfig.DefaultPacks, ","))
+	}
+	// Every request reloads the file, only rebuild the maps when it changed
+	// so the graphCache survives
+	if recipesMap == nil || !slices.Equal(loadedRecipes, recipes) {
+		loadedRecipes = recipes
+		buildRecipeMap(filterRecipesByPack(recipes, activePacks))
+		buildReverseGraph()
+	}
+	elementImages = images
+	storedImages = stored
+	elementDetails = details
+	elementAliases = patched.aliases
+	elementWeights = patched.weights
+	overrideReport = patched.report
+	return nil
+}
+
+// loadElementImages reads the mapped_elements.json written by "arachemy images".
+// A missing file is not an error, elements just have no image.
+func loadElementImages(file string) (map[string]string, error) {
+	images := make(map[string]string)
+	if file == "" {
+		return images, nil
+	}
+	data, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return images, nil
+	}
+	if err != nil {
+		return images, err
+	}
+
+	var mapped []struct {
+		Element      string `json:"Element"`
+		ElementImage string `json:"ElementImage"`
+	}
+	if err := json.Unmarshal(data, &mapped); err != nil {
+		return images, err
+	}
+	for _, m := range mapped {
+		if m.ElementImage != "" {
+			images[strings.ToLower(m.Element)] = m.ElementImage
+		}
+	}
+	return images, nil
+}
+
 // buildRecipeMap constructs the recipe and tier maps
 func buildRecipeMap(recipes []Recipe) {
 	fmt.Println("[DEBUG] Building recipe map")
 	recipesMap = make(map[string][][]string)
 	tierMap = make(map[string]int)
+	graphGeneration++
 	
 	for _, r := range recipes {
 		element := strings.ToLower(r.Element)
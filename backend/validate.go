@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DatasetReport is the outcome of validateDataset. Errors make the dataset
+// unusable, warnings are inconsistencies the solvers work around.
+type DatasetReport struct {
+	Recipes     int      `json:"recipes"`
+	Elements    int      `json:"elements"`
+	Unreachable []string `json:"unreachable"`
+	Errors      []string `json:"errors"`
+	Warnings    []string `json:"warnings"`
+}
+
+// OK reports whether the dataset has no errors
+func (r *DatasetReport) OK() bool {
+	return len(r.Errors) == 0
+}
+
+// validateDataset checks recipes for missing names, unknown ingredients,
+// duplicates, tier inversions and elements that cannot be made from the base elements
+func validateDataset(recipes []Recipe) *DatasetReport {
+	report := &DatasetReport{Recipes: len(recipes)}
+	if len(recipes) == 0 {
+		report.Errors = append(report.Errors, "dataset has no recipes")
+		return report
+	}
+
+	tiers := make(map[string]int)
+	made := make(map[string][][2]string)
+	seen := make(map[string]bool)
+	for i, r := range recipes {
+		element := strings.ToLower(strings.TrimSpace(r.Element))
+		a := strings.ToLower(strings.TrimSpace(r.Ingredient1))
+		b := strings.ToLower(strings.TrimSpace(r.Ingredient2))
+		if element == "" || a == "" || b == "" {
+			report.Errors = append(report.Errors, fmt.Sprintf("recipe #%d has an empty name: %q + %q = %q", i, r.Ingredient1, r.Ingredient2, r.Element))
+			continue
+		}
+		if r.Type < 0 {
+			report.Errors = append(report.Errors, fmt.Sprintf("recipe #%d: %s has negative tier %d", i, element, r.Type))
+		}
+		if t, ok := tiers[element]; ok && t != r.Type {
+			report.Errors = append(report.Errors, fmt.Sprintf("%s has conflicting tiers %d and %d", element, t, r.Type))
+		}
+		tiers[element] = r.Type
+
+		x, y := normalizeIngredients(a, b)
+		key := x + "+" + y + "=" + element
+		if seen[key] {
+			report.Warnings = append(report.Warnings, fmt.Sprintf("duplicate recipe %s + %s = %s", a, b, element))
+			continue
+		}
+		seen[key] = true
+		made[element] = append(made[element], [2]string{a, b})
+	}
+	report.Elements = len(made)
+
+	for element, recipes := range made {
+		for _, ingr := range recipes {
+			for _, name := range ingr {
+				if _, ok := made[name]; !ok && !baseElements[name] {
+					report.Errors = append(report.Errors, fmt.Sprintf("%s + %s = %s uses unknown element %s", ingr[0], ingr[1], element, name))
+				}
+			}
+			if !baseElements[element] && (tiers[ingr[0]] >= tiers[element] || tiers[ingr[1]] >= tiers[element]) {
+				report.Warnings = append(report.Warnings, fmt.Sprintf("%s + %s = %s is skipped by the solvers (ingredient tier not lower)", ingr[0], ingr[1], element))
+			}
+		}
+	}
+
+	// Forward closure from the base elements using tier-valid recipes only,
+	// which is what every solver can actually reach
+	reachable := make(map[string]bool)
+	for base := range baseElements {
+		reachable[base] = true
+	}
+	for changed := true; changed; {
+		changed = false
+		for element, recipes := range made {
+			if reachable[element] {
+				continue
+			}
+			for _, ingr := range recipes {
+				if reachable[ingr[0]] && reachable[ingr[1]] &&
+					tiers[ingr[0]] < tiers[element] && tiers[ingr[1]] < tiers[element] {
+					reachable[element] = true
+					changed = true
+					break
+				}
+			}
+		}
+	}
+	for element := range made {
+		if !reachable[element] {
+			report.Unreachable = append(report.Unreachable, element)
+		}
+	}
+
+	sort.Strings(report.Unreachable)
+	sort.Strings(report.Errors)
+	sort.Strings(report.Warnings)
+	return report
+}
+
+// validatePath checks that steps really make target: every step must be a
+// known recipe and every ingredient a base element or made by an earlier step
+func validatePath(target string, steps []string) error {
+	if baseElements[target] {
+		return nil
+	}
+	mutex.RLock()
+	defer mutex.RUnlock()
+
+	have := make(map[string]bool)
+	for base := range baseElements {
+		have[base] = true
+	}
+	for i, step := range steps {
+		a, b, result, ok := parseStep(step)
+		if !ok {
+			return fmt.Errorf("step %d %q is malformed", i+1, step)
+		}
+		if !have[a] || !have[b] {
+			return fmt.Errorf("step %d %q uses an ingredient that is not made yet", i+1, step)
+		}
+		known := false
+		for _, ingr := range recipesMap[result] {
+			if (ingr[0] == a && ingr[1] == b) || (ingr[0] == b && ingr[1] == a) {
+				known = true
+				break
+			}
+		}
+		if !known {
+			return fmt.Errorf("step %d %q is not a recipe", i+1, step)
+		}
+		have[result] = true
+	}
+	if !have[target] {
+		return fmt.Errorf("steps never make %s", target)
+	}
+	return nil
+}
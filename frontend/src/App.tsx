@@ -74,6 +74,9 @@ function App() {
         `${import.meta.env.VITE_BACKEND_URL}/find?${params.toString()}`
       );
       const data = await res.json();
+      if (!res.ok) {
+        throw new Error(`${data.error?.code}: ${data.error?.message}`);
+      }
       setResult(data);
       setIsMultiple(Array.isArray(data));
 
@@ -99,14 +102,15 @@ function App() {
   };
 
   
-  // Function to scrape data when component mounts
+  // Load the scraped recipes when component mounts; scraping itself is an
+  // admin job (POST /admin/scrape) and never runs from the page
   useEffect(() => {
     const fetchData = async () => {
       try {
         setScrapingStatus('loading');
         console.log('Scraping data from API...');
         console.log("Backend URL:", import.meta.env.VITE_BACKEND_URL);
-        const response = await axios.get<ScrapeResponse>(`${import.meta.env.VITE_BACKEND_URL}/scrape`);
+        const response = await axios.get<ScrapeResponse>(`${import.meta.env.VITE_BACKEND_URL}/recipes`);
         setRecipes(response.data.data);
         setScrapingStatus('success');
         console.log(`Successfully scrape`)